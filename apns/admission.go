@@ -0,0 +1,75 @@
+package apns
+
+import (
+	"errors"
+)
+
+var errQueueFull = errors.New("apns: Queue is full, dismissing command")
+
+// QueueState describes occupancy of the commands queue at the time a command is being admitted
+type QueueState struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// Occupancy returns the fraction of queue capacity that is in use
+func (s QueueState) Occupancy() float64 {
+	if s.Capacity <= 0 {
+		return 1
+	}
+
+	return float64(s.Length) / float64(s.Capacity)
+}
+
+// AdmissionController is consulted before a command is queued for execution. Returning an error refuses the command.
+type AdmissionController interface {
+	Admit(cmd CommandInterface, state QueueState) error
+}
+
+// AdmissionControllerFunc is an adapter allowing the use of ordinary functions as admission controllers
+type AdmissionControllerFunc func(cmd CommandInterface, state QueueState) error
+
+// Admit calls f(cmd, state)
+func (f AdmissionControllerFunc) Admit(cmd CommandInterface, state QueueState) error {
+	return f(cmd, state)
+}
+
+// CapacityAdmissionController admits commands as long as there is free space in the queue. It is the default admission controller.
+type CapacityAdmissionController struct {
+}
+
+// Admit implements AdmissionController interface
+func (ac *CapacityAdmissionController) Admit(cmd CommandInterface, state QueueState) error {
+	if state.Length >= state.Capacity {
+		return errQueueFull
+	}
+
+	return nil
+}
+
+// PriorityAdmissionController refuses low priority (5) notifications once queue occupancy reaches the threshold while still admitting high priority ones until the queue is full
+type PriorityAdmissionController struct {
+	// Threshold is the queue occupancy (0-1) from which low priority notifications are refused
+	Threshold float64
+}
+
+// NewPriorityAdmissionController returns admission controller refusing low priority notifications above the threshold occupancy
+func NewPriorityAdmissionController(threshold float64) *PriorityAdmissionController {
+	ac := new(PriorityAdmissionController)
+	ac.Threshold = threshold
+
+	return ac
+}
+
+// Admit implements AdmissionController interface
+func (ac *PriorityAdmissionController) Admit(cmd CommandInterface, state QueueState) error {
+	if state.Length >= state.Capacity {
+		return errQueueFull
+	}
+
+	if notification, ok := cmd.Data().(*Notification); ok && notification.Priority == 5 && state.Occupancy() >= ac.Threshold {
+		return errors.New("apns: Queue is under pressure, dismissing low priority command")
+	}
+
+	return nil
+}
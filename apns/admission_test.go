@@ -0,0 +1,22 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPriorityAdmissionController(t *testing.T) {
+	assert := assert.New(t)
+
+	ac := NewPriorityAdmissionController(0.7)
+
+	low := NewNotification()
+	low.Priority = 5
+	high := NewNotification()
+	high.Priority = 10
+
+	assert.Nil(ac.Admit(NewPushNotificationCommand(low), QueueState{Length: 69, Capacity: 100}), "Low priority should be admitted below threshold")
+	assert.NotNil(ac.Admit(NewPushNotificationCommand(low), QueueState{Length: 70, Capacity: 100}), "Low priority shouldn't be admitted above threshold")
+	assert.Nil(ac.Admit(NewPushNotificationCommand(high), QueueState{Length: 99, Capacity: 100}), "High priority should be admitted until the queue is full")
+	assert.Equal(errQueueFull, ac.Admit(NewPushNotificationCommand(high), QueueState{Length: 100, Capacity: 100}), "Nothing should be admitted to full queue")
+}
@@ -0,0 +1,103 @@
+package apns
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// UnknownApsKeysReject refuses notifications with unknown keys in aps dictionary
+	UnknownApsKeysReject = "reject"
+	// UnknownApsKeysWarn sends unknown keys of aps dictionary to APNS and logs a warning
+	UnknownApsKeysWarn = "warn"
+	// UnknownApsKeysPass sends unknown keys of aps dictionary to APNS silently
+	UnknownApsKeysPass = "pass"
+)
+
+// KnownApsKeys are keys of aps dictionary recognized by this package
+var KnownApsKeys = map[string]bool{
+	"alert":             true,
+	"badge":             true,
+	"sound":             true,
+	"content-available": true,
+	"category":          true,
+}
+
+// MarshalJSON implements custom marshalling of aps dictionary including its unknown keys
+func (a *Aps) MarshalJSON() ([]byte, error) {
+	type apsAlias Aps
+
+	data, err := json.Marshal((*apsAlias)(a))
+	if err != nil || len(a.UnknownKeys) == 0 {
+		return data, err
+	}
+
+	aps := make(map[string]interface{})
+	if err = json.Unmarshal(data, &aps); err != nil {
+		return nil, err
+	}
+
+	for key, value := range a.UnknownKeys {
+		if !KnownApsKeys[key] {
+			aps[key] = value
+		}
+	}
+
+	return json.Marshal(aps)
+}
+
+// UnmarshalJSON implements custom unmarshalling of aps dictionary keeping its unknown keys in UnknownKeys
+func (a *Aps) UnmarshalJSON(data []byte) error {
+	type apsAlias Aps
+
+	if err := json.Unmarshal(data, (*apsAlias)(a)); err != nil {
+		return err
+	}
+
+	var aps map[string]interface{}
+	if err := json.Unmarshal(data, &aps); err != nil {
+		return err
+	}
+
+	for key, value := range aps {
+		if KnownApsKeys[key] {
+			continue
+		}
+
+		if a.UnknownKeys == nil {
+			a.UnknownKeys = make(map[string]interface{})
+		}
+		a.UnknownKeys[key] = value
+	}
+
+	return nil
+}
+
+// CheckApsKeys applies UnknownApsKeys policy to unknown keys of notification's aps dictionary. Error is returned when they are rejected.
+func (c *Client) CheckApsKeys(n *Notification) error {
+	if n.Payload == nil || n.Payload.Aps == nil || len(n.Payload.Aps.UnknownKeys) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(n.Payload.Aps.UnknownKeys))
+	for key := range n.Payload.Aps.UnknownKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	switch c.Config.UnknownApsKeys {
+	case UnknownApsKeysPass:
+		return nil
+	case UnknownApsKeysWarn:
+		logger.Warningf("Notification #%s has unknown 'aps' keys %s, sending them to APNS", n.NotificationIdentifier, strings.Join(keys, ", "))
+		return nil
+	}
+
+	return errors.New("apns/notification: Unknown 'aps' keys " + strings.Join(keys, ", "))
+}
+
+func validUnknownApsKeysPolicy(policy string) bool {
+	return policy == UnknownApsKeysReject || policy == UnknownApsKeysWarn || policy == UnknownApsKeysPass
+}
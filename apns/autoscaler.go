@@ -0,0 +1,80 @@
+package apns
+
+import (
+	"time"
+)
+
+const (
+	// DefaultAutoscaleCooldown is the default time autoscaler waits after scaling before scaling again
+	DefaultAutoscaleCooldown = time.Second * 30
+
+	// DefaultAutoscaleUpOccupancy is the default queue occupancy from which autoscaler adds workers
+	DefaultAutoscaleUpOccupancy = 0.01
+
+	// autoscaleCheckInterval is how often autoscaler checks queue occupancy
+	autoscaleCheckInterval = time.Second
+)
+
+// autoscale scales workers between AutoscaleMinWorkers and AutoscaleMaxWorkers according to queue occupancy
+func (c *Client) autoscale() {
+	logger.Infof("Autoscaling between %d and %d worker(s)", c.Config.AutoscaleMinWorkers, c.Config.AutoscaleMaxWorkers)
+
+	ticker := time.NewTicker(autoscaleCheckInterval)
+	defer ticker.Stop()
+
+	var lastScaled time.Time
+	for now := range ticker.C {
+		if now.Sub(lastScaled) < c.Config.AutoscaleCooldown {
+			continue
+		}
+
+		// queue grows on purpose during maintenance
+		if c.Maintenance().Active {
+			continue
+		}
+
+		current := c.WorkerCount()
+		state := QueueState{Length: len(c.commandsQueue), Capacity: cap(c.commandsQueue)}
+		target := autoscaleTarget(current, c.Config.AutoscaleMinWorkers, c.Config.AutoscaleMaxWorkers, state.Occupancy(), c.Config.AutoscaleUpOccupancy, c.Config.AutoscaleDownOccupancy)
+		if target == current {
+			continue
+		}
+
+		logger.Infof("Autoscaling workers from %d to %d at %.2f%% queue occupancy", current, target, state.Occupancy()*100)
+		if err := c.SetWorkerCount(target); err != nil {
+			logger.Errorf("Autoscaling failed: %s", err)
+		}
+		lastScaled = now
+	}
+}
+
+// autoscaleTarget returns the number of workers for given queue occupancy. Workers are doubled when occupancy reaches upOccupancy
+// and reduced by a quarter when it doesn't exceed downOccupancy, always staying between min and max.
+func autoscaleTarget(current uint32, min uint32, max uint32, occupancy float64, upOccupancy float64, downOccupancy float64) uint32 {
+	target := current
+
+	if occupancy >= upOccupancy {
+		target = current * 2
+	} else if occupancy <= downOccupancy {
+		step := current / 4
+		if step == 0 {
+			step = 1
+		}
+
+		if step < current {
+			target = current - step
+		}
+	}
+
+	if target < min {
+		target = min
+	}
+	if target > max {
+		target = max
+	}
+	if target == 0 {
+		target = 1
+	}
+
+	return target
+}
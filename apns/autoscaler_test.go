@@ -0,0 +1,17 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAutoscaleTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(uint32(8), autoscaleTarget(4, 2, 16, 0.5, 0.01, 0), "Busy queue should double workers")
+	assert.Equal(uint32(16), autoscaleTarget(12, 2, 16, 0.5, 0.01, 0), "Workers shouldn't exceed maximum")
+	assert.Equal(uint32(6), autoscaleTarget(8, 2, 16, 0, 0.01, 0), "Empty queue should remove a quarter of workers")
+	assert.Equal(uint32(2), autoscaleTarget(2, 2, 16, 0, 0.01, 0), "Workers shouldn't go below minimum")
+	assert.Equal(uint32(8), autoscaleTarget(8, 2, 16, 0.005, 0.01, 0), "Workers should be kept between thresholds")
+	assert.Equal(uint32(2), autoscaleTarget(1, 2, 16, 0.005, 0.01, 0), "Workers should be raised to minimum")
+}
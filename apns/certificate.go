@@ -0,0 +1,160 @@
+package apns
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"golang.org/x/crypto/pkcs12"
+	"io/ioutil"
+	"strings"
+)
+
+var (
+	// certificateUIDOID is the OID of subject's UID attribute which holds the bundle id the certificate was issued for
+	certificateUIDOID = asn1.ObjectIdentifier{0, 9, 2342, 19200300, 100, 1, 1}
+
+	// certificateTopicsOID is the OID of Apple's certificate extension listing all topics of an universal push certificate
+	certificateTopicsOID = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 6, 3, 6}
+)
+
+// certificateTopics returns all topics (bundle ids) parsed from the certificate's UID attribute and Apple's topics extension
+func certificateTopics(certificate tls.Certificate) (topics []string, err error) {
+	if len(certificate.Certificate) == 0 {
+		err = errors.New("apns: Certificate chain is empty")
+		return
+	}
+
+	var leaf *x509.Certificate
+	leaf, err = x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return
+	}
+
+	for _, name := range leaf.Subject.Names {
+		if !name.Type.Equal(certificateUIDOID) {
+			continue
+		}
+
+		if uid, ok := name.Value.(string); ok && uid != "" {
+			topics = appendTopic(topics, uid)
+		}
+	}
+
+	for _, extension := range leaf.Extensions {
+		if !extension.Id.Equal(certificateTopicsOID) {
+			continue
+		}
+
+		// extension value is a sequence of topic strings each followed by a sequence of push types allowed for that topic
+		var sequence asn1.RawValue
+		_, err = asn1.Unmarshal(extension.Value, &sequence)
+		if err != nil {
+			return
+		}
+
+		data := sequence.Bytes
+		for len(data) > 0 {
+			var item asn1.RawValue
+			data, err = asn1.Unmarshal(data, &item)
+			if err != nil {
+				return
+			}
+
+			if item.Class == asn1.ClassUniversal && item.Tag == asn1.TagUTF8String {
+				topics = appendTopic(topics, string(item.Bytes))
+			}
+		}
+	}
+
+	return
+}
+
+func appendTopic(topics []string, topic string) []string {
+	for _, t := range topics {
+		if t == topic {
+			return topics
+		}
+	}
+
+	return append(topics, topic)
+}
+
+// validateTopic checks whether notification topic is one of the topics the loaded certificate was issued for
+func (c *Client) validateTopic(topic string) error {
+	c.certificateLock.RLock()
+	defer c.certificateLock.RUnlock()
+
+	if topic == "" || len(c.topics) == 0 {
+		return nil
+	}
+
+	for _, t := range c.topics {
+		if t == topic {
+			return nil
+		}
+	}
+
+	return errors.New("apns: Topic \"" + topic + "\" doesn't match certificate topics (" + strings.Join(c.topics, ", ") + ")")
+}
+
+// loadCertificate loads certificate and key files of the config and parses certificate topics
+func loadCertificate(config *ClientConfig) (certificate tls.Certificate, topics []string, err error) {
+	if config.CertificateP12File != "" {
+		certificate, err = loadP12Certificate(config.CertificateP12File, config.CertificateP12Password)
+	} else {
+		certificate, err = tls.LoadX509KeyPair(config.CertificateFile, config.CertificatePrivateKeyFile)
+	}
+	if err != nil {
+		return
+	}
+
+	var topicsErr error
+	topics, topicsErr = certificateTopics(certificate)
+	if topicsErr != nil {
+		logger.Warningf("Couldn't parse certificate topics, topic validation is disabled: %s", topicsErr)
+	}
+	logger.Debugf("Certificate topics: %+v", topics)
+
+	return
+}
+
+// loadP12Certificate decodes certificate and private key from PKCS#12 file as exported by Keychain Access
+func loadP12Certificate(file string, password string) (certificate tls.Certificate, err error) {
+	var data []byte
+	data, err = ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+
+	var key interface{}
+	var cert *x509.Certificate
+	key, cert, err = pkcs12.Decode(data, password)
+	if err != nil {
+		err = errors.New("apns: Couldn't decode PKCS#12 file " + file + ": " + err.Error())
+		return
+	}
+
+	certificate = tls.Certificate{
+		Certificate: [][]byte{cert.Raw},
+		PrivateKey:  key,
+		Leaf:        cert,
+	}
+
+	return
+}
+
+// ReloadCertificate loads certificate and key files again. Workers reconnect with the reloaded certificate after their next command,
+// new Feedback service connections use it right away. Current certificate is kept when the files can't be loaded.
+func (c *Client) ReloadCertificate() error {
+	_, err := c.Reload(c.Config)
+
+	return err
+}
+
+func (c *Client) currentCertificate() tls.Certificate {
+	c.certificateLock.RLock()
+	defer c.certificateLock.RUnlock()
+
+	return c.certificate
+}
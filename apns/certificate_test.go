@@ -0,0 +1,119 @@
+package apns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCertificate(t *testing.T, uid string, topics ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName: "Apple Push Services: " + uid,
+			ExtraNames: []pkix.AttributeTypeAndValue{{Type: certificateUIDOID, Value: uid}},
+		},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	if len(topics) > 0 {
+		var items []interface{}
+		for _, topic := range topics {
+			items = append(items, asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagUTF8String, Bytes: []byte(topic)})
+			items = append(items, []asn1.RawValue{{Class: asn1.ClassUniversal, Tag: asn1.TagUTF8String, Bytes: []byte("app")}})
+		}
+
+		value, err := asn1.Marshal(items)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template.ExtraExtensions = []pkix.Extension{{Id: certificateTopicsOID, Value: value}}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestCertificateTopics(t *testing.T) {
+	assert := assert.New(t)
+
+	topics, err := certificateTopics(newTestCertificate(t, "com.example.app"))
+	assert.Nil(err, "Parsing certificate shouldn't produce error")
+	assert.Equal([]string{"com.example.app"}, topics, "UID should be the only topic")
+
+	topics, err = certificateTopics(newTestCertificate(t, "com.example.app", "com.example.app", "com.example.app.voip"))
+	assert.Nil(err, "Parsing certificate shouldn't produce error")
+	assert.Equal([]string{"com.example.app", "com.example.app.voip"}, topics, "Topics should include extension values without duplicates")
+}
+
+func TestClientValidateTopic(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.topics = []string{"com.example.app", "com.example.app.voip"}
+
+	assert.Nil(c.validateTopic(""), "Empty topic should be valid")
+	assert.Nil(c.validateTopic("com.example.app.voip"), "Certificate topic should be valid")
+	assert.Contains(c.validateTopic("com.example.other").Error(), "doesn't match certificate topics", "Invalid topic error message")
+}
+
+func writeTestCertificate(t *testing.T, config *ClientConfig, certificate tls.Certificate) {
+	key, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(config.CertificateFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600)
+	if err == nil {
+		err = ioutil.WriteFile(config.CertificatePrivateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientReloadCertificate(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "apns-certificate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := new(Client)
+	c.Config = &ClientConfig{CertificateFile: filepath.Join(dir, "cert.pem"), CertificatePrivateKeyFile: filepath.Join(dir, "key.pem")}
+	c.workers = []*worker{{id: 1, client: c}}
+
+	writeTestCertificate(t, c.Config, newTestCertificate(t, "com.example.app"))
+	assert.Nil(c.ReloadCertificate(), "Reloading valid certificate shouldn't produce error")
+	assert.Equal([]string{"com.example.app"}, c.topics, "Topics of reloaded certificate should be used")
+	assert.Equal(int32(1), c.workers[0].renewConnection, "Workers should be asked to reconnect")
+
+	ioutil.WriteFile(c.Config.CertificateFile, []byte("garbage"), 0600)
+	assert.NotNil(c.ReloadCertificate(), "Reloading invalid certificate should produce error")
+	assert.Equal([]string{"com.example.app"}, c.topics, "Current certificate should be kept")
+}
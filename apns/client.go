@@ -3,11 +3,11 @@ package apns
 import (
 	"crypto/tls"
 	"errors"
-	"fmt"
-	"github.com/spf13/pflag"
 	"io"
 	"net"
 	"runtime"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,22 +17,7 @@ const (
 	CommandsQueueSize = 100000
 )
 
-var (
-	env                              = "sandbox"
-	commandsQueueSize         uint64 = CommandsQueueSize
-	numberOfWorkers                  = uint32(runtime.NumCPU() * 2)
-	certifcateFile            string
-	certificatePrivateKeyFile string
-	workerID                  uint32
-)
-
-func setupClientCommandLineFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&env, "env", env, "Environment of Apple's APNS and Feedback service gateways. For production use specify \"production\", for testing specify \"sandbox\".")
-	fs.Uint64Var(&commandsQueueSize, "max-notifications", commandsQueueSize, "Number of notification that can be queued for processing at once. Once the queue is full all requests to raw push notification endpoint will result in 503 Service Unavailable response.")
-	fs.Uint32Var(&numberOfWorkers, "workers", numberOfWorkers, "Number of workers that concurently process push notifications. Defaults to 2 * Number of CPU cores.")
-	fs.StringVar(&certifcateFile, "cert", certifcateFile, "Absolute path to certificate file. Certificate is expected be in PEM format.")
-	fs.StringVar(&certificatePrivateKeyFile, "cert-key", certificatePrivateKeyFile, "Absolute path to certificate private key file. Certificate key is expected be in PEM format.")
-}
+var workerID uint32
 
 // ClientConfig holds some configuration options for Client
 type ClientConfig struct {
@@ -48,18 +33,134 @@ type ClientConfig struct {
 	// CertificatePrivateKey is absolute path to APNS certificate private key file
 	CertificatePrivateKeyFile string
 
+	// CertificateP12File is absolute path to PKCS#12 (.p12) file holding both certificate and private key, it takes precedence over PEM files
+	CertificateP12File string
+
+	// CertificateP12Password is the password of PKCS#12 file
+	CertificateP12Password string
+
 	// CommandsQueueSize sets the queue size for push notifications
 	CommandsQueueSize uint64
+
+	// APNSGatewayProduction is FQDN of Apple's APNS production gateway
+	APNSGatewayProduction string
+
+	// APNSGatewaySandbox is FQDN of Apple's APNS sandbox gateway
+	APNSGatewaySandbox string
+
+	// APNSGatewayPort is Apple's APNS port number
+	APNSGatewayPort uint16
+
+	// FeedbackGatewayProduction is FQDN of Apple's Feedback service production gateway
+	FeedbackGatewayProduction string
+
+	// FeedbackGatewaySandbox is FQDN of Apple's Feedback service sandbox gateway
+	FeedbackGatewaySandbox string
+
+	// FeedbackGatewayPort is Apple's Feedback service port number
+	FeedbackGatewayPort uint16
+
+	// FailureWebhookURL is URL notified about notifications that couldn't be sent
+	FailureWebhookURL string
+
+	// DevMode enables logging of binary protocol frames
+	DevMode bool
+
+	// DevShowTokens disables redaction of device tokens in developer mode frame dumps
+	DevShowTokens bool
+
+	// AdmissionController decides whether a command may be queued. Defaults to CapacityAdmissionController
+	AdmissionController AdmissionController
+
+	// MaxRetries is the number of times a command is retried after a transient failure
+	MaxRetries uint32
+
+	// RetryBackoff is the delay before the first retry, it doubles with every following retry
+	RetryBackoff time.Duration
+
+	// RetryMaxBackoff is the maximum delay between retries
+	RetryMaxBackoff time.Duration
+
+	// ResendWindowSize is the number of recently written commands each worker remembers to resend them after an error response
+	ResendWindowSize uint32
+
+	// FeedbackPollInterval is the interval of automatic Feedback service checks, zero disables polling
+	FeedbackPollInterval time.Duration
+
+	// FeedbackWebhookURL is URL notified about expired devices found by automatic Feedback service checks
+	FeedbackWebhookURL string
+
+	// FeedbackStore persists expired devices reported by Feedback service. Defaults to MemoryFeedbackStore
+	FeedbackStore FeedbackStore
+
+	// StallTimeout is how long a worker may execute a single command before watchdog closes its connection, zero disables the watchdog
+	StallTimeout time.Duration
+
+	// ResultStore keeps outcomes of notifications. Defaults to MemoryResultStore of DefaultResultStoreSize
+	ResultStore ResultStore
+
+	// MaintenanceStatusURL is URL of APNS maintenance status source responding with {"maintenance": bool, "reason": string}, empty disables checks
+	MaintenanceStatusURL string
+
+	// MaintenanceCheckInterval is the interval of maintenance status source checks
+	MaintenanceCheckInterval time.Duration
+
+	// UnknownApsKeys is the policy for unknown keys of aps dictionary checked by CheckApsKeys, one of "reject", "warn" or "pass"
+	UnknownApsKeys string
+
+	// ConnectionProbe keeps an additional read-only connection to APNS gateway to detect gateway closing connections early.
+	// Workers then reconnect before writing their next command.
+	ConnectionProbe bool
+
+	// HealthCheckInterval is the interval of idle worker connection checks, zero disables them
+	HealthCheckInterval time.Duration
+
+	// MaxIdleTime is how long a worker's connection may stay idle before the worker connects again, zero keeps idle connections
+	MaxIdleTime time.Duration
+
+	// AutoscaleMinWorkers is the minimum number of workers kept by autoscaler
+	AutoscaleMinWorkers uint32
+
+	// AutoscaleMaxWorkers is the maximum number of workers started by autoscaler, zero disables autoscaling
+	AutoscaleMaxWorkers uint32
+
+	// AutoscaleCooldown is the time autoscaler waits after scaling before scaling again
+	AutoscaleCooldown time.Duration
+
+	// AutoscaleUpOccupancy is the queue occupancy (0-1) from which autoscaler doubles workers
+	AutoscaleUpOccupancy float64
+
+	// AutoscaleDownOccupancy is the queue occupancy (0-1) up to which autoscaler removes a quarter of workers
+	AutoscaleDownOccupancy float64
 }
 
-// NewClientConfig returns new client config
+// NewClientConfig returns new client config with default values
 func NewClientConfig() (config *ClientConfig) {
 	config = new(ClientConfig)
-	config.Env = env
-	config.NumberOfWorkers = numberOfWorkers
-	config.CommandsQueueSize = commandsQueueSize
-	config.CertificateFile = certifcateFile
-	config.CertificatePrivateKeyFile = certificatePrivateKeyFile
+	config.Env = "sandbox"
+	config.NumberOfWorkers = uint32(runtime.NumCPU() * 2)
+	config.CommandsQueueSize = CommandsQueueSize
+	config.APNSGatewayProduction = APNSGatewayProduction
+	config.APNSGatewaySandbox = APNSGatewaySandbox
+	config.APNSGatewayPort = APNSGatewayPort
+	config.FeedbackGatewayProduction = FeedbackGatewayProduction
+	config.FeedbackGatewaySandbox = FeedbackGatewaySandbox
+	config.FeedbackGatewayPort = FeedbackGatewayPort
+	config.AdmissionController = new(CapacityAdmissionController)
+	config.MaxRetries = DefaultMaxRetries
+	config.RetryBackoff = DefaultRetryBackoff
+	config.RetryMaxBackoff = DefaultRetryMaxBackoff
+	config.ResendWindowSize = DefaultResendWindowSize
+	config.FeedbackStore = NewMemoryFeedbackStore()
+	config.ResultStore = NewMemoryResultStore(DefaultResultStoreSize)
+	config.StallTimeout = DefaultStallTimeout
+	config.MaintenanceCheckInterval = DefaultMaintenanceCheckInterval
+	config.UnknownApsKeys = UnknownApsKeysReject
+	config.HealthCheckInterval = DefaultHealthCheckInterval
+	config.MaxIdleTime = DefaultMaxIdleTime
+	config.AutoscaleMinWorkers = 1
+	config.AutoscaleCooldown = DefaultAutoscaleCooldown
+	config.AutoscaleUpOccupancy = DefaultAutoscaleUpOccupancy
 
 	return
 }
@@ -68,9 +169,53 @@ func NewClientConfig() (config *ClientConfig) {
 type Client struct {
 	Config             *ClientConfig
 	certificate        tls.Certificate
+	topics             []string
+	certificateLock    sync.RWMutex
 	commandsQueue      chan CommandInterface
 	workerQueue        chan chan CommandInterface
 	commandErrorsQueue chan CommandErrorInterface
+
+	errorSubscribers     map[chan CommandErrorInterface]bool
+	errorSubscribersLock sync.Mutex
+
+	attempts     map[CommandInterface]uint32
+	attemptsLock sync.Mutex
+
+	feedbackCall     *feedbackCall
+	feedbackCallLock sync.Mutex
+
+	feedbackCallbacks []FeedbackCallback
+	feedbackModified  time.Time
+	feedbackLock      sync.Mutex
+
+	workers     []*worker
+	workersLock sync.Mutex
+
+	// workerCount is the number of workers the client should run, it changes with SetWorkerCount
+	workerCount uint32
+	scalingLock sync.Mutex
+
+	activeWorkers  int32
+	stalledWorkers uint64
+	gatewayCloses  uint64
+	deadWorkers    int32
+	workerRestarts uint64
+	idleReconnects uint64
+
+	// pending counts commands taken from the queue but not yet received by a worker and commands waiting for a retry
+	pending int32
+
+	maintenance maintenance
+
+	lastReload *ReloadReport
+	reloadLock sync.Mutex
+}
+
+// feedbackCall is a Feedback service check in progress shared by concurrent callers
+type feedbackCall struct {
+	done chan bool
+	rsp  *FeedbackResponse
+	err  error
 }
 
 // NewClient creates a new Client
@@ -84,13 +229,35 @@ func NewClient(config *ClientConfig) (client *Client, err error) {
 	// validate and create certificate
 	logger.Debug("Validating certificate files...")
 	var certificate tls.Certificate
-	certificate, err = tls.LoadX509KeyPair(config.CertificateFile, config.CertificatePrivateKeyFile)
+	var topics []string
+	certificate, topics, err = loadCertificate(config)
 
 	if err != nil {
 		logger.Fatalf("Error was encountered during certificate validation: %s", err)
 		return
 	}
 
+	if config.UnknownApsKeys == "" {
+		config.UnknownApsKeys = UnknownApsKeysReject
+	}
+
+	if !validUnknownApsKeysPolicy(config.UnknownApsKeys) {
+		err = errors.New("apns: Unknown aps keys policy \"" + config.UnknownApsKeys + "\" should be one of reject, warn or pass")
+		return nil, err
+	}
+
+	if config.AdmissionController == nil {
+		config.AdmissionController = new(CapacityAdmissionController)
+	}
+
+	if config.FeedbackStore == nil {
+		config.FeedbackStore = NewMemoryFeedbackStore()
+	}
+
+	if config.ResultStore == nil {
+		config.ResultStore = NewMemoryResultStore(DefaultResultStoreSize)
+	}
+
 	// setup channels
 	logger.Debugf("Setting up command queue: %+v", config.CommandsQueueSize)
 	nCh := make(chan CommandInterface, config.CommandsQueueSize)
@@ -107,9 +274,12 @@ func NewClient(config *ClientConfig) (client *Client, err error) {
 
 	client.Config = config
 	client.certificate = certificate
+	client.topics = topics
 	client.commandsQueue = nCh
 	client.workerQueue = wCh
 	client.commandErrorsQueue = eCh
+	client.errorSubscribers = make(map[chan CommandErrorInterface]bool)
+	client.attempts = make(map[CommandInterface]uint32)
 
 	err = client.init()
 	if err != nil {
@@ -130,14 +300,12 @@ func (c *Client) init() (err error) {
 
 	logger.Infof("Initializing %d worker(s)", c.Config.NumberOfWorkers)
 
+	c.workerCount = c.Config.NumberOfWorkers
 	for i = 0; i < c.Config.NumberOfWorkers; i++ {
-		atomic.AddUint32(&workerID, 1)
-		worker, workerErr := newWorker(int(workerID), c)
+		worker, workerErr := c.startWorker()
 		if workerErr != nil {
 			//TODO issue warning about this and try to create the worker again later
 			logger.Warningf("Worker #%d couldn't be initialized: %s", worker.id, workerErr)
-		} else {
-			// logger.Infof("%s%+v %s", "Worker #", worker.id, "ready")
 		}
 	}
 
@@ -151,16 +319,46 @@ func (c *Client) init() (err error) {
 				go func() {
 					//TODO logging
 					logger.Warningf("Received error: %s for command %s", commandError, commandError.GetCommand())
+					c.publishError(commandError)
+					c.notifyFailureWebhook(commandError)
 				}()
 			}
 		}
 	}()
 
+	if c.Config.FeedbackPollInterval > 0 {
+		go c.pollFeedbackService()
+	}
+
+	if c.Config.StallTimeout > 0 {
+		go c.watchWorkers()
+	}
+
+	if c.Config.MaintenanceStatusURL != "" {
+		if c.Config.MaintenanceCheckInterval <= 0 {
+			c.Config.MaintenanceCheckInterval = DefaultMaintenanceCheckInterval
+		}
+
+		go c.pollMaintenanceStatus()
+	}
+
+	if c.Config.ConnectionProbe {
+		go c.probeGateway()
+	}
+
+	if c.Config.AutoscaleMaxWorkers > 0 {
+		go c.autoscale()
+	}
+
 	// main dispatch loop
 	go func() {
 		for {
+			// commands stay buffered in the queue during maintenance
+			c.awaitMaintenanceEnd()
+
 			select {
 			case cmd := <-c.commandsQueue:
+				atomic.AddInt32(&c.pending, 1)
 				go func() {
 					logger.Debugf("Received command from queue %+v", cmd)
 					select {
@@ -180,22 +378,62 @@ func (c *Client) init() (err error) {
 
 // ExecuteCommand queues command for execution
 func (c *Client) ExecuteCommand(cmd CommandInterface) error {
+	state := QueueState{Length: len(c.commandsQueue), Capacity: cap(c.commandsQueue)}
+
+	if err := c.Config.AdmissionController.Admit(cmd, state); err != nil {
+		c.recordResult(cmd, ResultFailed, err)
+		close(cmd.Errors())
+		logger.Warningf("Command wasn't admitted for execution, dropping command: %s (%s)", cmd, err)
+		return NewCommandError(err, cmd)
+	}
+
+	// recorded before queueing so it can't overwrite the outcome reported by a worker
+	c.recordResult(cmd, ResultQueued, nil)
+
 	select {
 	case c.commandsQueue <- cmd:
 		logger.Debugf("Scheduled %s for execution", cmd)
 		break
 
 	default:
+		c.recordResult(cmd, ResultFailed, errQueueFull)
 		close(cmd.Errors())
 		logger.Warningf("Command queue is full, dropping command: %s", cmd)
-		return NewCommandError(errors.New("apns: Queue is full, dismissing command"), cmd)
+		return NewCommandError(errQueueFull, cmd)
 	}
 
 	return nil
 }
 
-// CheckFeedbackService connects to Apple's feedback service and returns FeedbackResponse object
-func (c *Client) CheckFeedbackService() (rsp *FeedbackResponse, err error) {
+// CheckFeedbackService connects to Apple's feedback service, adds expired devices to feedback store and returns FeedbackResponse object.
+// Concurrent callers share a single connection and receive the same FeedbackResponse which must not be modified.
+func (c *Client) CheckFeedbackService() (*FeedbackResponse, error) {
+	c.feedbackCallLock.Lock()
+	if call := c.feedbackCall; call != nil {
+		c.feedbackCallLock.Unlock()
+		logger.Debug("Waiting for Feedback service check in progress")
+		<-call.done
+		return call.rsp, call.err
+	}
+
+	call := &feedbackCall{done: make(chan bool)}
+	c.feedbackCall = call
+	c.feedbackCallLock.Unlock()
+
+	call.rsp, call.err = c.checkFeedbackService()
+	if call.err == nil {
+		call.err = c.storeFeedback(call.rsp)
+	}
+
+	c.feedbackCallLock.Lock()
+	c.feedbackCall = nil
+	c.feedbackCallLock.Unlock()
+	close(call.done)
+
+	return call.rsp, call.err
+}
+
+func (c *Client) checkFeedbackService() (rsp *FeedbackResponse, err error) {
 	var conn net.Conn
 	var read int
 	var responseBytes = make([]byte, 38)
@@ -205,18 +443,18 @@ func (c *Client) CheckFeedbackService() (rsp *FeedbackResponse, err error) {
 
 	var gateway string
 	if c.isProdEnv() {
-		gateway = FeedbackGatewayProduction
+		gateway = c.Config.FeedbackGatewayProduction
 	} else {
-		gateway = FeedbackGatewaySandbox
+		gateway = c.Config.FeedbackGatewaySandbox
 	}
 
 	tlsConfig := &tls.Config{}
 	tlsConfig.ServerName = gateway
-	tlsConfig.Certificates = []tls.Certificate{c.certificate}
+	tlsConfig.Certificates = []tls.Certificate{c.currentCertificate()}
 
-	logger.Infof("Connecting to %s:%d", tlsConfig.ServerName, FeedbackGatewayPort)
+	logger.Infof("Connecting to %s:%d", tlsConfig.ServerName, c.Config.FeedbackGatewayPort)
 
-	conn, err = dialer.Dial("tcp", fmt.Sprintf("%s:%d", tlsConfig.ServerName, FeedbackGatewayPort))
+	conn, err = dialer.Dial("tcp", net.JoinHostPort(tlsConfig.ServerName, strconv.Itoa(int(c.Config.FeedbackGatewayPort))))
 	if err != nil {
 		logger.Error("Error connecting feedback service")
 		return
@@ -239,6 +477,7 @@ func (c *Client) CheckFeedbackService() (rsp *FeedbackResponse, err error) {
 		logger.Debugf("Read %d bytes %+v", read, responseBytes)
 
 		if read > 0 {
+			c.dumpFeedbackFrame("Feedback service", responseBytes[:read])
 			rsp.addEntryFromBytes(responseBytes)
 		}
 
@@ -257,8 +496,6 @@ func (c *Client) CheckFeedbackService() (rsp *FeedbackResponse, err error) {
 			logger.Warningf("Error reading response from feedback service: %s", err)
 		}
 	}
-
-	return
 }
 
 func (c *Client) isProdEnv() bool {
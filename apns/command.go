@@ -19,12 +19,14 @@ type CommandErrorInterface interface {
 	Error() string
 	GetError() error
 	GetCommand() CommandInterface
+	GetStatusCode() uint8
 }
 
 // CommandError is a generic command error
 type CommandError struct {
 	commandError error
 	command      CommandInterface
+	statusCode   uint8
 }
 
 ///
@@ -60,11 +62,12 @@ func NewCommandError(err error, cmd CommandInterface) *CommandError {
 // NewCommandErrorFromAPNSResponse creates and returns error representing APNS response
 func NewCommandErrorFromAPNSResponse(data []byte, cmd CommandInterface) (commandError *CommandError) {
 	var err error
+	var statusCode uint8
 
 	if len(data) != 6 {
 		err = errors.New("apns: Unrecognized APNS response")
 	} else {
-		statusCode := uint8(data[1])
+		statusCode = uint8(data[1])
 		notificationIdentifier := hex.EncodeToString(data[2:])
 
 		if apnsErrorDescription := PushNotificationErrorStatuses[statusCode]; apnsErrorDescription != "" {
@@ -73,6 +76,7 @@ func NewCommandErrorFromAPNSResponse(data []byte, cmd CommandInterface) (command
 	}
 
 	commandError = NewCommandError(err, cmd)
+	commandError.statusCode = statusCode
 	return
 }
 
@@ -98,3 +102,12 @@ func (ge *CommandError) GetError() error {
 func (ge *CommandError) GetCommand() CommandInterface {
 	return ge.command
 }
+
+// GetStatusCode returns APNS error status code or 0 if the error doesn't originate from APNS response
+func (ge *CommandError) GetStatusCode() uint8 {
+	if ge != nil {
+		return ge.statusCode
+	}
+
+	return 0
+}
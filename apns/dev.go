@@ -0,0 +1,151 @@
+package apns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ErrorResponseCommandValue is the value of error response command in apns binary protocol
+const ErrorResponseCommandValue = 8
+
+var frameItemNames = map[uint8]string{
+	DeviceTokenItemID:            "device token",
+	PayloadItemID:                "payload",
+	NotificationIdentifierItemID: "notification identifier",
+	ExpirationDateItemID:         "expiration date",
+	PriorityItemID:               "priority",
+}
+
+// dumpOutgoingFrame logs hex dump and decoded representation of a frame written to APNS gateway in developer mode
+func (c *Client) dumpOutgoingFrame(source string, data []byte) {
+	if !c.Config.DevMode {
+		return
+	}
+
+	dump, description := decodeNotificationFrame(data, c.Config.DevShowTokens)
+	logger.Infof("%s outgoing frame (%d bytes)\n%s%s", source, len(data), hex.Dump(dump), description)
+}
+
+// dumpErrorResponseFrame logs hex dump and decoded representation of APNS error response in developer mode
+func (c *Client) dumpErrorResponseFrame(source string, data []byte) {
+	if !c.Config.DevMode {
+		return
+	}
+
+	logger.Infof("%s incoming error response frame (%d bytes)\n%s%s", source, len(data), hex.Dump(data), decodeErrorResponseFrame(data))
+}
+
+// dumpFeedbackFrame logs hex dump and decoded representation of Feedback service tuple in developer mode
+func (c *Client) dumpFeedbackFrame(source string, data []byte) {
+	if !c.Config.DevMode {
+		return
+	}
+
+	dump, description := decodeFeedbackFrame(data, c.Config.DevShowTokens)
+	logger.Infof("%s incoming feedback frame (%d bytes)\n%s%s", source, len(data), hex.Dump(dump), description)
+}
+
+// decodeNotificationFrame returns a copy of send push notification command with redacted device token and its human readable description
+func decodeNotificationFrame(data []byte, showTokens bool) (dump []byte, description string) {
+	dump = make([]byte, len(data))
+	copy(dump, data)
+
+	buffer := &bytes.Buffer{}
+
+	if len(data) < 5 {
+		fmt.Fprintf(buffer, "  malformed frame header\n")
+		return dump, buffer.String()
+	}
+
+	fmt.Fprintf(buffer, "  command: %d\n", data[0])
+	fmt.Fprintf(buffer, "  frame length: %d\n", binary.BigEndian.Uint32(data[1:5]))
+
+	offset := 5
+	for offset < len(data) {
+		if offset+3 > len(data) {
+			fmt.Fprintf(buffer, "  malformed item header at offset %d\n", offset)
+			break
+		}
+
+		itemID := data[offset]
+		itemLength := int(binary.BigEndian.Uint16(data[offset+1 : offset+3]))
+		start, end := offset+3, offset+3+itemLength
+
+		if end > len(data) {
+			fmt.Fprintf(buffer, "  item %d (%s) length %d exceeds frame\n", itemID, frameItemNames[itemID], itemLength)
+			break
+		}
+
+		value := data[start:end]
+		var readable string
+
+		switch itemID {
+		case DeviceTokenItemID:
+			if showTokens {
+				readable = hex.EncodeToString(value)
+			} else {
+				readable = "<redacted>"
+				for i := start; i < end; i++ {
+					dump[i] = 0
+				}
+			}
+		case PayloadItemID:
+			readable = string(value)
+		case ExpirationDateItemID:
+			if len(value) == ExpirationDateItemLength {
+				readable = time.Unix(int64(binary.BigEndian.Uint32(value)), 0).UTC().String()
+			} else {
+				readable = hex.EncodeToString(value)
+			}
+		case PriorityItemID:
+			if len(value) == PriorityItemLength {
+				readable = fmt.Sprintf("%d", value[0])
+			} else {
+				readable = hex.EncodeToString(value)
+			}
+		default:
+			readable = hex.EncodeToString(value)
+		}
+
+		fmt.Fprintf(buffer, "  item %d (%s) length %d: %s\n", itemID, frameItemNames[itemID], itemLength, readable)
+		offset = end
+	}
+
+	return dump, buffer.String()
+}
+
+// decodeErrorResponseFrame returns human readable description of APNS error response
+func decodeErrorResponseFrame(data []byte) string {
+	if len(data) != 6 {
+		return "  malformed error response\n"
+	}
+
+	return fmt.Sprintf("  command: %d\n  status: %d (%s)\n  notification identifier: %s\n", data[0], data[1], PushNotificationErrorStatuses[data[1]], hex.EncodeToString(data[2:]))
+}
+
+// decodeFeedbackFrame returns a copy of feedback tuple with redacted device token and its human readable description
+func decodeFeedbackFrame(data []byte, showTokens bool) (dump []byte, description string) {
+	dump = make([]byte, len(data))
+	copy(dump, data)
+
+	if len(data) < TimestampItemLength+DeviceTokenLengthItemLength {
+		return dump, "  malformed feedback tuple\n"
+	}
+
+	timestamp := time.Unix(int64(binary.BigEndian.Uint32(data[0:4])), 0).UTC()
+	tokenLength := binary.BigEndian.Uint16(data[4:6])
+
+	token := "<redacted>"
+	if showTokens {
+		token = hex.EncodeToString(data[6:])
+	} else {
+		for i := 6; i < len(dump); i++ {
+			dump[i] = 0
+		}
+	}
+
+	return dump, fmt.Sprintf("  timestamp: %s\n  token length: %d\n  device token: %s\n", timestamp, tokenLength, token)
+}
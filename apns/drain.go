@@ -0,0 +1,47 @@
+package apns
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// drainCheckInterval is how often Drain checks whether all commands were executed
+const drainCheckInterval = time.Millisecond * 50
+
+// Drain waits until the commands queue is empty and no worker is executing a command. It returns ctx error if ctx is done first.
+// Commands should no longer be executed while draining.
+func (c *Client) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if c.idle() {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			logger.Warningf("Client stopped draining with %d queued command(s)", len(c.commandsQueue))
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) idle() bool {
+	if len(c.commandsQueue) > 0 || atomic.LoadInt32(&c.pending) > 0 {
+		return false
+	}
+
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	for _, w := range c.workers {
+		if atomic.LoadInt64(&w.busySince) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,27 @@
+package apns
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClientDrain(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.commandsQueue = make(chan CommandInterface, 1)
+
+	w := &worker{id: 1, client: c}
+	c.workers = []*worker{w}
+
+	assert.Nil(c.Drain(context.Background()), "Idle client should be drained right away")
+
+	w.busySince = time.Now().UnixNano()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+
+	assert.Equal(context.DeadlineExceeded, c.Drain(ctx), "Busy worker should keep client draining")
+}
@@ -18,6 +18,7 @@ const (
 // FeedbackResponse holds all device entries from feedback service response
 type FeedbackResponse struct {
 	Devices []*FeedbackDeviceEntry `json:"devices"`
+	Next    string                 `json:"next,omitempty"`
 }
 
 // NewFeedbackResponse returns a new feedback tuple object
@@ -62,3 +63,38 @@ func (fs *FeedbackResponse) addEntryFromBytes(data []byte) (err error) {
 
 	return
 }
+
+// ExpiredDevices returns up to limit expired devices from feedback store with timestamp not before since, starting at the cursor.
+// Response's Next holds the cursor to continue from.
+func (c *Client) ExpiredDevices(since time.Time, cursor string, limit int) (rsp *FeedbackResponse, err error) {
+	rsp = NewFeedbackResponse()
+	rsp.Devices, rsp.Next, err = c.Config.FeedbackStore.Query(since, cursor, limit)
+
+	return
+}
+
+// FeedbackModified returns the time expired devices were last added to feedback store
+func (c *Client) FeedbackModified() time.Time {
+	c.feedbackLock.Lock()
+	defer c.feedbackLock.Unlock()
+
+	return c.feedbackModified
+}
+
+func (c *Client) storeFeedback(rsp *FeedbackResponse) (err error) {
+	if len(rsp.Devices) == 0 {
+		return
+	}
+
+	err = c.Config.FeedbackStore.Add(rsp.Devices)
+	if err != nil {
+		logger.Errorf("Couldn't store %d expired device(s): %s", len(rsp.Devices), err)
+		return
+	}
+
+	c.feedbackLock.Lock()
+	c.feedbackModified = time.Now()
+	c.feedbackLock.Unlock()
+
+	return
+}
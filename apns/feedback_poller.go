@@ -0,0 +1,54 @@
+package apns
+
+import (
+	"time"
+)
+
+// FeedbackCallback is called with expired devices found by the feedback poller
+type FeedbackCallback func(rsp *FeedbackResponse)
+
+// OnFeedback registers a callback invoked every time the feedback poller finds expired devices
+func (c *Client) OnFeedback(callback FeedbackCallback) {
+	c.feedbackLock.Lock()
+	defer c.feedbackLock.Unlock()
+
+	c.feedbackCallbacks = append(c.feedbackCallbacks, callback)
+}
+
+// pollFeedbackService checks Feedback service every FeedbackPollInterval
+func (c *Client) pollFeedbackService() {
+	logger.Infof("Polling Feedback service every %s", c.Config.FeedbackPollInterval)
+
+	ticker := time.NewTicker(c.Config.FeedbackPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rsp, err := c.CheckFeedbackService()
+		if err != nil {
+			logger.Errorf("Feedback service poll failed: %s", err)
+			continue
+		}
+
+		if len(rsp.Devices) == 0 {
+			logger.Debug("Feedback service poll found no expired devices")
+			continue
+		}
+
+		logger.Infof("Feedback service poll found %d expired device(s)", len(rsp.Devices))
+
+		c.feedbackLock.Lock()
+		callbacks := c.feedbackCallbacks
+		c.feedbackLock.Unlock()
+
+		for _, callback := range callbacks {
+			callback(rsp)
+		}
+
+		if c.Config.FeedbackWebhookURL != "" {
+			err = postWebhook(c.Config.FeedbackWebhookURL, rsp)
+			if err != nil {
+				logger.Errorf("Feedback webhook couldn't be delivered: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package apns
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FeedbackStore persists expired devices reported by Feedback service so they can be retrieved incrementally
+type FeedbackStore interface {
+	// Add stores entries in the order they were reported
+	Add(entries []*FeedbackDeviceEntry) error
+
+	// Query returns up to limit entries with timestamp not before since, starting at the position identified by cursor (empty cursor starts at the beginning),
+	// and cursor to continue from, which also retrieves entries added later once all current ones were read. Non-positive limit returns all entries.
+	Query(since time.Time, cursor string, limit int) (entries []*FeedbackDeviceEntry, next string, err error)
+}
+
+// ErrInvalidCursor is returned by FeedbackStore when the cursor wasn't issued by the store
+var ErrInvalidCursor = errors.New("apns: Invalid cursor")
+
+// MemoryFeedbackStore keeps expired devices in memory
+type MemoryFeedbackStore struct {
+	lock    sync.RWMutex
+	entries []*FeedbackDeviceEntry
+}
+
+// NewMemoryFeedbackStore returns an empty in-memory feedback store
+func NewMemoryFeedbackStore() *MemoryFeedbackStore {
+	return new(MemoryFeedbackStore)
+}
+
+// Add implements FeedbackStore interface
+func (s *MemoryFeedbackStore) Add(entries []*FeedbackDeviceEntry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries = append(s.entries, entries...)
+
+	return nil
+}
+
+// Query implements FeedbackStore interface. Cursor is the index of the first entry to examine.
+func (s *MemoryFeedbackStore) Query(since time.Time, cursor string, limit int) (entries []*FeedbackDeviceEntry, next string, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	start := 0
+	if cursor != "" {
+		start, err = strconv.Atoi(cursor)
+		if err != nil || start < 0 || start > len(s.entries) {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	entries = make([]*FeedbackDeviceEntry, 0)
+
+	i := start
+	for ; i < len(s.entries) && (limit <= 0 || len(entries) < limit); i++ {
+		if !s.entries[i].Timestamp.Before(since) {
+			entries = append(entries, s.entries[i])
+		}
+	}
+
+	next = strconv.Itoa(i)
+
+	return
+}
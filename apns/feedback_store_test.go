@@ -0,0 +1,44 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryFeedbackStoreQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewMemoryFeedbackStore()
+	base := time.Unix(1445000000, 0)
+
+	for i := 0; i < 5; i++ {
+		entry := NewFeedbackDeviceEntry()
+		entry.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		store.Add([]*FeedbackDeviceEntry{entry})
+	}
+
+	entries, next, err := store.Query(time.Time{}, "", 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(entries, 2, "First page should be full")
+
+	entries, next, err = store.Query(time.Time{}, next, 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(entries, 2, "Second page should be full")
+	assert.Equal(base.Add(time.Hour*3), entries[1].Timestamp, "Pages should follow each other")
+
+	entries, next, err = store.Query(time.Time{}, next, 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(entries, 1, "Last page should hold the rest")
+
+	entries, _, err = store.Query(time.Time{}, next, 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(entries, 0, "Nothing was added after the last page")
+
+	entries, _, err = store.Query(base.Add(time.Hour*3), "", 0)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(entries, 2, "Only entries since given time should be returned")
+
+	_, _, err = store.Query(time.Time{}, "bogus", 2)
+	assert.Equal(ErrInvalidCursor, err, "Unknown cursor should be refused")
+}
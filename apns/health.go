@@ -0,0 +1,68 @@
+package apns
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default interval of idle connection checks
+	DefaultHealthCheckInterval = time.Second * 30
+
+	// DefaultMaxIdleTime is the default time after which an idle worker reconnects
+	DefaultMaxIdleTime = time.Minute * 10
+
+	// healthCheckReadTimeout is how long health check waits for the connection to report it was closed
+	healthCheckReadTimeout = time.Millisecond * 10
+)
+
+// checkConnection validates connection of an idle worker. The connection is established again when it was idle longer than
+// MaxIdleTime, when gateway closed it or when APNS sent a late error response (gateway closes the connection after it).
+// Connection that can't be established again is marked stale, worker then retries before its next command.
+func (w *worker) checkConnection() {
+	idle := time.Since(time.Unix(0, atomic.LoadInt64(&w.lastActive)))
+	if w.client.Config.MaxIdleTime > 0 && idle >= w.client.Config.MaxIdleTime {
+		logger.Infof("Worker #%d reconnecting after being idle for %s", w.id, idle)
+		w.refreshIdleConnection()
+		return
+	}
+
+	// nothing was written since the last response so the only things to read are a late error response or connection close
+	responseBytes := make([]byte, 6)
+	w.tlsConn.SetReadDeadline(time.Now().Add(healthCheckReadTimeout))
+	read, err := w.tlsConn.Read(responseBytes)
+
+	if read > 0 {
+		logger.Warningf("Worker #%d received late error response while idle", w.id)
+		w.client.dumpErrorResponseFrame(fmt.Sprintf("Worker #%d", w.id), responseBytes[:read])
+
+		if commandError, ok := w.handleErrorResponse(responseBytes[:read], nil).(*CommandError); ok {
+			w.client.reportError(commandError)
+		}
+	} else if err == nil {
+		return
+	} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return
+	} else {
+		logger.Warningf("Worker #%d idle connection failed health check: %s", w.id, err)
+	}
+
+	w.refreshIdleConnection()
+}
+
+// refreshIdleConnection connects an idle worker again
+func (w *worker) refreshIdleConnection() {
+	atomic.AddUint64(&w.client.idleReconnects, 1)
+
+	w.disconnect()
+	err := w.connect()
+	if err != nil {
+		logger.Warningf("Worker #%d couldn't reconnect while idle: %s", w.id, err)
+		atomic.StoreInt32(&w.staleConnection, 1)
+		return
+	}
+
+	atomic.StoreInt64(&w.lastActive, time.Now().UnixNano())
+}
@@ -0,0 +1,29 @@
+package apns
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerCheckConnection(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.Config = &ClientConfig{APNSGatewayPort: 1, MaxIdleTime: time.Hour}
+
+	conn, peer := net.Pipe()
+	w := &worker{id: 1, client: c, gateway: "127.0.0.1", tlsConn: tls.Client(conn, &tls.Config{InsecureSkipVerify: true})}
+	atomic.StoreInt64(&w.lastActive, time.Now().UnixNano())
+
+	w.checkConnection()
+	assert.Equal(uint64(0), c.Stats().IdleReconnects, "Healthy connection shouldn't be refreshed")
+
+	peer.Close()
+	w.checkConnection()
+	assert.Equal(uint64(1), c.Stats().IdleReconnects, "Closed connection should be refreshed")
+	assert.Equal(int32(1), atomic.LoadInt32(&w.staleConnection), "Connection that couldn't be refreshed should be marked stale")
+}
@@ -0,0 +1,150 @@
+package apns
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultMaintenanceCheckInterval is the default interval of maintenance status source checks
+const DefaultMaintenanceCheckInterval = time.Minute
+
+// MaintenanceState describes APNS maintenance window. During maintenance commands are buffered in the commands queue instead of being sent.
+type MaintenanceState struct {
+	Active bool      `json:"active"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitempty"`
+
+	// Manual is true when maintenance was toggled by SetMaintenance, Source is true when maintenance is reported by status source
+	Manual bool `json:"manual"`
+	Source bool `json:"source"`
+}
+
+// MaintenanceCallback is called with the new state every time maintenance starts or ends
+type MaintenanceCallback func(state MaintenanceState)
+
+// maintenanceStatus is the response expected from maintenance status source
+type maintenanceStatus struct {
+	Maintenance bool   `json:"maintenance"`
+	Reason      string `json:"reason"`
+}
+
+type maintenance struct {
+	lock      sync.Mutex
+	state     MaintenanceState
+	resumed   chan bool
+	callbacks []MaintenanceCallback
+}
+
+// OnMaintenance registers a callback invoked every time maintenance starts or ends
+func (c *Client) OnMaintenance(callback MaintenanceCallback) {
+	c.maintenance.lock.Lock()
+	defer c.maintenance.lock.Unlock()
+
+	c.maintenance.callbacks = append(c.maintenance.callbacks, callback)
+}
+
+// Maintenance returns current maintenance state
+func (c *Client) Maintenance() MaintenanceState {
+	c.maintenance.lock.Lock()
+	defer c.maintenance.lock.Unlock()
+
+	return c.maintenance.state
+}
+
+// SetMaintenance manually starts or ends maintenance. Maintenance reported by status source stays active until the source reports otherwise.
+func (c *Client) SetMaintenance(active bool, reason string) {
+	c.updateMaintenance(func(state *MaintenanceState) {
+		state.Manual = active
+		if active {
+			state.Reason = reason
+		}
+	})
+}
+
+func (c *Client) updateMaintenance(update func(state *MaintenanceState)) {
+	m := &c.maintenance
+
+	m.lock.Lock()
+	wasActive := m.state.Active
+
+	update(&m.state)
+	m.state.Active = m.state.Manual || m.state.Source
+
+	if m.state.Active == wasActive {
+		m.lock.Unlock()
+		return
+	}
+
+	if m.state.Active {
+		m.state.Since = time.Now()
+		m.resumed = make(chan bool)
+		logger.Warningf("APNS maintenance started (%s), commands are buffered until it ends", m.state.Reason)
+	} else {
+		logger.Infof("APNS maintenance ended after %s, resuming sending of %d buffered command(s)", time.Since(m.state.Since), len(c.commandsQueue))
+		m.state.Reason = ""
+		m.state.Since = time.Time{}
+		close(m.resumed)
+	}
+
+	state := m.state
+	callbacks := m.callbacks
+	m.lock.Unlock()
+
+	for _, callback := range callbacks {
+		callback(state)
+	}
+}
+
+// awaitMaintenanceEnd blocks while maintenance is active
+func (c *Client) awaitMaintenanceEnd() {
+	c.maintenance.lock.Lock()
+	active := c.maintenance.state.Active
+	resumed := c.maintenance.resumed
+	c.maintenance.lock.Unlock()
+
+	if active {
+		<-resumed
+	}
+}
+
+// pollMaintenanceStatus checks MaintenanceStatusURL every MaintenanceCheckInterval
+func (c *Client) pollMaintenanceStatus() {
+	logger.Infof("Checking APNS maintenance status %s every %s", c.Config.MaintenanceStatusURL, c.Config.MaintenanceCheckInterval)
+
+	ticker := time.NewTicker(c.Config.MaintenanceCheckInterval)
+	defer ticker.Stop()
+
+	for ; true; <-ticker.C {
+		status, err := checkMaintenanceStatus(c.Config.MaintenanceStatusURL)
+		if err != nil {
+			logger.Errorf("APNS maintenance status check failed, keeping current state: %s", err)
+			continue
+		}
+
+		c.updateMaintenance(func(state *MaintenanceState) {
+			state.Source = status.Maintenance
+			if status.Maintenance && !state.Manual {
+				state.Reason = status.Reason
+			}
+		})
+	}
+}
+
+func checkMaintenanceStatus(url string) (status *maintenanceStatus, err error) {
+	rsp, err := webhookClient.Get(url)
+	if err != nil {
+		return
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != 200 {
+		return nil, errors.New("apns: Maintenance status source responded with " + rsp.Status)
+	}
+
+	status = new(maintenanceStatus)
+	err = json.NewDecoder(rsp.Body).Decode(status)
+
+	return
+}
@@ -0,0 +1,43 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestClientMaintenance(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.commandsQueue = make(chan CommandInterface, 1)
+
+	var transitions []bool
+	c.OnMaintenance(func(state MaintenanceState) {
+		transitions = append(transitions, state.Active)
+	})
+
+	c.SetMaintenance(true, "scheduled")
+	c.updateMaintenance(func(state *MaintenanceState) { state.Source = true })
+
+	resumed := make(chan bool)
+	go func() {
+		c.awaitMaintenanceEnd()
+		close(resumed)
+	}()
+
+	c.SetMaintenance(false, "")
+	assert.True(c.Maintenance().Active, "Maintenance reported by source should stay active")
+	assert.Equal("scheduled", c.Maintenance().Reason)
+
+	c.updateMaintenance(func(state *MaintenanceState) { state.Source = false })
+
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("Dispatching should resume after maintenance")
+	}
+
+	assert.False(c.Maintenance().Active)
+	assert.Equal([]bool{true, false}, transitions, "Callbacks should be called on transitions only")
+}
@@ -37,14 +37,14 @@ const (
 
 // Alert struct represents alert dictionary (https://developer.apple.com/library/prerelease/watchos/documentation/NetworkingInternet/Conceptual/RemoteNotificationsPG/Chapters/ApplePushService.html#//apple_ref/doc/uid/TP40008194-CH100-SW20)
 type Alert struct {
-	Title                  string   `json:"title,omitempty", mapstructure:"title"`
-	Body                   string   `json:"body,omitempty", mapstructure:"body"`
-	TitleLocalizationKey   string   `json:"title-loc-key,omitempty", mapstructure:"title-loc-key"`
-	TitleLocalizationdArgs []string `json:"title-loc-args,omitempty", mapstructure:"title-loc-args"`
-	ActionLocalizationKey  string   `json:"action-loc-key,omitempty", mapstructure:"action-loc-key"`
-	BodyLocalizationKey    string   `json:"loc-key,omitempty", mapstructure:"loc-key"`
-	BodyLocalizationArgs   []string `json:"loc-args,omitempty", mapstructure:"loc-args"`
-	LaunchImage            string   `json:"launch-image,omitempty", mapstructure:"launch-image"`
+	Title                  string   `json:"title,omitempty" mapstructure:"title"`
+	Body                   string   `json:"body,omitempty" mapstructure:"body"`
+	TitleLocalizationKey   string   `json:"title-loc-key,omitempty" mapstructure:"title-loc-key"`
+	TitleLocalizationdArgs []string `json:"title-loc-args,omitempty" mapstructure:"title-loc-args"`
+	ActionLocalizationKey  string   `json:"action-loc-key,omitempty" mapstructure:"action-loc-key"`
+	BodyLocalizationKey    string   `json:"loc-key,omitempty" mapstructure:"loc-key"`
+	BodyLocalizationArgs   []string `json:"loc-args,omitempty" mapstructure:"loc-args"`
+	LaunchImage            string   `json:"launch-image,omitempty" mapstructure:"launch-image"`
 }
 
 // Aps struct represents aps dictionary (https://developer.apple.com/library/prerelease/watchos/documentation/NetworkingInternet/Conceptual/RemoteNotificationsPG/Chapters/ApplePushService.html#//apple_ref/doc/uid/TP40008194-CH100-SW2)
@@ -54,6 +54,9 @@ type Aps struct {
 	Sound            string      `json:"sound,omitempty"`
 	ContentAvailable int         `json:"content-available,omitempty"`
 	Category         string      `json:"category,omitempty"`
+
+	// UnknownKeys holds keys of aps dictionary not known to this package, they are sent to APNS as they are (see ClientConfig.UnknownApsKeys)
+	UnknownKeys map[string]interface{} `json:"-"`
 }
 
 // NewAps creates a new blank notification payload aps object
@@ -124,10 +127,15 @@ func (p *Payload) JSONString() (string, error) {
 // Notification struct represents push notification
 type Notification struct {
 	DeviceToken            string     `json:"deviceToken,omitempty"`
+	Topic                  string     `json:"topic,omitempty"`
 	Payload                *Payload   `json:"payload,omitempty"`
 	NotificationIdentifier string     `json:"identifier,omitempty"`
 	ExpirationDate         *time.Time `json:"expires,omitempty"`
 	Priority               uint8      `json:"priority,omitempty"`
+	// CorrelationID is an opaque client-supplied identifier, it isn't sent to APNS
+	CorrelationID string `json:"correlationId,omitempty"`
+	// AppID selects the application (certificate) the notification is sent by, it isn't sent to APNS
+	AppID string `json:"appId,omitempty"`
 }
 
 // NewNotification creates a new blank notification object
@@ -175,6 +183,7 @@ func (n *Notification) UnmarshalJSON(data []byte) (err error) {
 	}
 
 	n.DeviceToken = fakeNotification.DeviceToken
+	n.Topic = fakeNotification.Topic
 
 	// set provided notification identifier otherwise keep generated one
 	if fakeNotification.NotificationIdentifier != "" {
@@ -182,6 +191,8 @@ func (n *Notification) UnmarshalJSON(data []byte) (err error) {
 	}
 	n.ExpirationDate = fakeNotification.ExpirationDate
 	n.Priority = fakeNotification.Priority
+	n.CorrelationID = fakeNotification.CorrelationID
+	n.AppID = fakeNotification.AppID
 
 	n.Payload = NewPayload()
 	n.Payload.customValues = fakeNotification.Payload.CustomValues
@@ -256,7 +267,7 @@ func (n *Notification) Bytes() ([]byte, error) {
 	if n.ExpirationDate != nil {
 		binary.Write(frameBuffer, binary.BigEndian, uint8(ExpirationDateItemID))
 		binary.Write(frameBuffer, binary.BigEndian, uint16(ExpirationDateItemLength))
-		binary.Write(frameBuffer, binary.BigEndian, n.ExpirationDate.Unix())
+		binary.Write(frameBuffer, binary.BigEndian, uint32(n.ExpirationDate.Unix()))
 	}
 
 	// Priority
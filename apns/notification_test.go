@@ -2,6 +2,7 @@ package apns
 
 import (
 	// "errors"
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
 	"strconv"
 	"testing"
@@ -125,3 +126,44 @@ func TestNotificationPayloadMarshalling(t *testing.T) {
 	assert.Nil(notificationError, "Marshalling shouldn't produce error")
 	assert.Contains(notificationJSONString, referenceJSONString, "JSON string should be equal")
 }
+
+func TestNotificationCorrelationID(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewNotification()
+	err := json.Unmarshal([]byte(`{"deviceToken":"0000000000000000000000000000000000000000000000000000000000000000","identifier":"aabbccdd","correlationId":"order-42","payload":{"aps":{"alert":"Hi!"}}}`), n)
+	assert.Nil(err, "Unmarshalling shouldn't produce error")
+	assert.Equal("order-42", n.CorrelationID, "Correlation ID should be kept")
+
+	withoutCorrelationID := NewNotification()
+	withoutCorrelationID.NotificationIdentifier = n.NotificationIdentifier
+	withoutCorrelationID.DeviceToken = n.DeviceToken
+	withoutCorrelationID.Payload = n.Payload
+
+	frame, _ := n.Bytes()
+	referenceFrame, _ := withoutCorrelationID.Bytes()
+	assert.Equal(referenceFrame, frame, "Correlation ID shouldn't be sent to APNS")
+}
+
+func TestApsUnknownKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	n := NewNotification()
+	err := json.Unmarshal([]byte(`{"payload":{"aps":{"alert":"Hi","mutable-content":1,"thread-id":"t"}}}`), n)
+	assert.Nil(err)
+	assert.Equal("Hi", n.Payload.Aps.Alert)
+	assert.Len(n.Payload.Aps.UnknownKeys, 2, "Unknown keys should be kept")
+
+	c := &Client{Config: &ClientConfig{UnknownApsKeys: UnknownApsKeysReject}}
+	err = c.CheckApsKeys(n)
+	if assert.NotNil(err, "Unknown keys should be rejected") {
+		assert.Equal("apns/notification: Unknown 'aps' keys mutable-content, thread-id", err.Error())
+	}
+
+	c.Config.UnknownApsKeys = UnknownApsKeysPass
+	assert.Nil(c.CheckApsKeys(n), "Unknown keys should pass")
+
+	data, err := json.Marshal(n.Payload.Aps)
+	assert.Nil(err)
+	assert.JSONEq(`{"alert":"Hi","mutable-content":1,"thread-id":"t"}`, string(data), "Unknown keys should be sent to APNS")
+}
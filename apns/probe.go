@@ -0,0 +1,93 @@
+package apns
+
+import (
+	"crypto/tls"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// probeMinBackoff is the delay before connection probe connects again after gateway closed its connection
+	probeMinBackoff = time.Second
+
+	// probeMaxBackoff is the maximum delay between failed connection probe attempts
+	probeMaxBackoff = time.Minute
+)
+
+// probeGateway keeps a read-only connection to APNS gateway. Nothing is ever written to it so APNS never responds, the only thing
+// a read returns is gateway closing the connection. When that happens connections of all workers are considered abandoned as well
+// and workers reconnect before writing their next command instead of losing it to a dead connection.
+func (c *Client) probeGateway() {
+	backoff := probeMinBackoff
+
+	for {
+		start := time.Now()
+		err := c.probeConnection()
+
+		// a connection that lasted long is a regular close, start over with short backoff
+		if time.Since(start) > probeMaxBackoff {
+			backoff = probeMinBackoff
+		}
+
+		logger.Warningf("Connection probe lost gateway connection (%s), reconnecting in %s", err, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > probeMaxBackoff {
+			backoff = probeMaxBackoff
+		}
+	}
+}
+
+// probeConnection connects to APNS gateway and blocks until the connection is closed
+func (c *Client) probeConnection() error {
+	gateway := c.Config.APNSGatewaySandbox
+	if c.isProdEnv() {
+		gateway = c.Config.APNSGatewayProduction
+	}
+
+	dialer := &net.Dialer{KeepAlive: time.Second * 10}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(gateway, strconv.Itoa(int(c.Config.APNSGatewayPort))), &tls.Config{
+		ServerName:   gateway,
+		Certificates: []tls.Certificate{c.currentCertificate()},
+	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	logger.Infof("Connection probe connected to %s", conn.RemoteAddr())
+
+	_, err = conn.Read(make([]byte, 6))
+
+	atomic.AddUint64(&c.gatewayCloses, 1)
+	c.markConnectionsStale()
+
+	return err
+}
+
+// markConnectionsStale makes all workers reconnect before executing their next command
+func (c *Client) markConnectionsStale() {
+	c.workersLock.Lock()
+	defer c.workersLock.Unlock()
+
+	logger.Warningf("Gateway closed probe connection, %d worker(s) will reconnect before sending", len(c.workers))
+
+	for _, w := range c.workers {
+		atomic.StoreInt32(&w.staleConnection, 1)
+	}
+}
+
+// refreshStaleConnection reconnects the worker when its connection was marked stale
+func (w *worker) refreshStaleConnection() error {
+	if !atomic.CompareAndSwapInt32(&w.staleConnection, 1, 0) {
+		return nil
+	}
+
+	logger.Infof("Worker #%d reconnecting, gateway may have abandoned its connection", w.id)
+	w.disconnect()
+
+	return w.connect()
+}
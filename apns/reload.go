@@ -0,0 +1,186 @@
+package apns
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// redactedValue replaces values of secret settings in config changes
+const redactedValue = "[redacted]"
+
+// hotReloadSettings are ClientConfig settings applied by Reload without restart
+var hotReloadSettings = map[string]bool{
+	"CertificateFile":           true,
+	"CertificatePrivateKeyFile": true,
+	"CertificateP12File":        true,
+	"CertificateP12Password":    true,
+}
+
+// redactedSettings are ClientConfig settings whose values are never reported
+var redactedSettings = map[string]bool{
+	"CertificateP12Password": true,
+}
+
+// ConfigChange describes a setting that differs between running and reloaded configuration
+type ConfigChange struct {
+	Setting string `json:"setting"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+
+	// RequiresRestart is true when the change isn't applied until the process is restarted
+	RequiresRestart bool `json:"requiresRestart"`
+}
+
+// ReloadReport describes the outcome of the last configuration reload. Error is set when certificate couldn't be loaded,
+// certificate settings aren't applied then.
+type ReloadReport struct {
+	Time    time.Time      `json:"time"`
+	Changes []ConfigChange `json:"changes"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// DiffConfig returns changes of settings between two configs which must be pointers to structs of the same type. Setting names are prefixed
+// with prefix, values of settings named in redacted are hidden as well as user info of URLs. Only settings of basic types, durations,
+// IP addresses and slices are compared, stores, controllers and other settings that can't be set from command line are skipped.
+// All changes require restart.
+func DiffConfig(prefix string, old interface{}, new interface{}, redacted map[string]bool) (changes []ConfigChange) {
+	oldValue := reflect.ValueOf(old).Elem()
+	newValue := reflect.ValueOf(new).Elem()
+
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Interface, reflect.Func, reflect.Map, reflect.Ptr, reflect.Chan, reflect.Struct:
+			continue
+		}
+
+		oldField := oldValue.Field(i).Interface()
+		newField := newValue.Field(i).Interface()
+		if reflect.DeepEqual(oldField, newField) {
+			continue
+		}
+
+		change := ConfigChange{
+			Setting:         prefix + field.Name,
+			Old:             redactSetting(oldField),
+			New:             redactSetting(newField),
+			RequiresRestart: true,
+		}
+
+		if redacted[field.Name] {
+			change.Old = redactedValue
+			change.New = redactedValue
+		}
+
+		changes = append(changes, change)
+	}
+
+	return
+}
+
+// redactSetting formats setting value hiding credentials of URLs
+func redactSetting(value interface{}) string {
+	formatted := fmt.Sprint(value)
+
+	if u, err := url.Parse(formatted); err == nil && u.User != nil {
+		u.User = url.User("redacted")
+		return u.String()
+	}
+
+	return formatted
+}
+
+// Reload compares config with the running configuration, applies certificate settings and reloads the certificate. Other changes are
+// reported as requiring restart. Changes are additional changes of settings outside of the client (e.g. HTTP server) to include in the report.
+// The report is logged and kept for Stats, current certificate is kept when the new one can't be loaded.
+func (c *Client) Reload(config *ClientConfig, changes ...ConfigChange) (report *ReloadReport, err error) {
+	report = &ReloadReport{Time: time.Now(), Changes: make([]ConfigChange, 0)}
+
+	for _, change := range DiffConfig("", c.Config, config, redactedSettings) {
+		change.RequiresRestart = !hotReloadSettings[change.Setting]
+		report.Changes = append(report.Changes, change)
+	}
+	report.Changes = append(report.Changes, changes...)
+
+	defer func() {
+		c.reloadLock.Lock()
+		c.lastReload = report
+		c.reloadLock.Unlock()
+
+		for _, change := range report.Changes {
+			if change.RequiresRestart {
+				logger.Warningf("Configuration change of %s (%s -> %s) requires restart", change.Setting, change.Old, change.New)
+			} else {
+				logger.Infof("Configuration change of %s (%s -> %s) applied", change.Setting, change.Old, change.New)
+			}
+		}
+	}()
+
+	certificate, topics, err := loadCertificate(config)
+	if err != nil {
+		logger.Errorf("Certificate couldn't be reloaded, keeping current one: %s", err)
+		report.Error = err.Error()
+		return
+	}
+
+	oldDescription := describeCertificate(c.currentCertificate())
+	newDescription := describeCertificate(certificate)
+
+	c.certificateLock.Lock()
+	c.certificate = certificate
+	c.topics = topics
+	c.Config.CertificateFile = config.CertificateFile
+	c.Config.CertificatePrivateKeyFile = config.CertificatePrivateKeyFile
+	c.Config.CertificateP12File = config.CertificateP12File
+	c.Config.CertificateP12Password = config.CertificateP12Password
+	c.certificateLock.Unlock()
+
+	c.workersLock.Lock()
+	for _, w := range c.workers {
+		atomic.StoreInt32(&w.renewConnection, 1)
+	}
+	c.workersLock.Unlock()
+
+	if oldDescription != newDescription {
+		report.Changes = append(report.Changes, ConfigChange{Setting: "Certificate", Old: oldDescription, New: newDescription})
+	}
+
+	logger.Info("Certificate reloaded")
+
+	return
+}
+
+// LastReload returns the report of the last configuration reload, nil when configuration wasn't reloaded yet
+func (c *Client) LastReload() *ReloadReport {
+	c.reloadLock.Lock()
+	defer c.reloadLock.Unlock()
+
+	return c.lastReload
+}
+
+// describeCertificate identifies certificate by its subject, expiry and fingerprint without revealing any key material
+func describeCertificate(certificate tls.Certificate) string {
+	if len(certificate.Certificate) == 0 {
+		return ""
+	}
+
+	fingerprint := sha256.Sum256(certificate.Certificate[0])
+	description := "sha256:" + hex.EncodeToString(fingerprint[:8])
+
+	if leaf, err := x509.ParseCertificate(certificate.Certificate[0]); err == nil {
+		description = leaf.Subject.CommonName + " " + description + ", expires " + leaf.NotAfter.UTC().Format(time.RFC3339)
+	}
+
+	return description
+}
@@ -0,0 +1,95 @@
+package apns
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// DefaultResendWindowSize is the default number of recently written commands each worker remembers for resending
+const DefaultResendWindowSize = 100
+
+// resendable is implemented by commands that can be re-created for sending again after they were completed
+type resendable interface {
+	resendCommand() CommandInterface
+}
+
+// resendCommand returns a new command for the same notification
+func (cmd *PushNotificationCommand) resendCommand() CommandInterface {
+	return NewPushNotificationCommand(cmd.Notification)
+}
+
+// rememberSent adds command to the window of commands written to the current connection
+func (w *worker) rememberSent(cmd CommandInterface) {
+	size := int(w.client.Config.ResendWindowSize)
+	if size == 0 {
+		return
+	}
+
+	w.sent = append(w.sent, cmd)
+	if len(w.sent) > size {
+		w.sent = w.sent[len(w.sent)-size:]
+	}
+}
+
+// forgetSent clears the window of commands written to the current connection
+func (w *worker) forgetSent() {
+	w.sent = nil
+}
+
+// handleErrorResponse finds the command APNS error response refers to and resends every command written after it, since APNS drops them.
+// It returns the error for the command that's being executed.
+func (w *worker) handleErrorResponse(data []byte, cmd CommandInterface) error {
+	if len(data) != 6 {
+		return NewCommandErrorFromAPNSResponse(data, cmd)
+	}
+
+	identifier := hex.EncodeToString(data[2:])
+
+	failedIndex := -1
+	for i, sent := range w.sent {
+		if sent.Identifier() == identifier {
+			failedIndex = i
+		}
+	}
+
+	if failedIndex == -1 || w.sent[failedIndex] == cmd {
+		w.forgetSent()
+		return NewCommandErrorFromAPNSResponse(data, cmd)
+	}
+
+	failed := w.sent[failedIndex]
+	logger.Warningf("Worker #%d received error response for previously written %s", w.id, failed)
+	w.client.reportError(NewCommandErrorFromAPNSResponse(data, failed))
+
+	for _, sent := range w.sent[failedIndex+1:] {
+		if sent == cmd {
+			continue
+		}
+
+		resend, ok := sent.(resendable)
+		if !ok {
+			logger.Warningf("Worker #%d can't resend %s dropped by APNS", w.id, sent)
+			continue
+		}
+
+		logger.Infof("Worker #%d resending %s dropped by APNS", w.id, sent)
+		w.client.resendCommand(resend.resendCommand())
+	}
+
+	w.forgetSent()
+
+	return &transientError{errors.New("apns/worker: Command was dropped by APNS after failure of " + failed.String())}
+}
+
+// resendCommand queues already completed command again bypassing admission control
+func (c *Client) resendCommand(cmd CommandInterface) {
+	c.recordResult(cmd, ResultQueued, nil)
+
+	select {
+	case c.commandsQueue <- cmd:
+		break
+	default:
+		logger.Warningf("Command queue is full, %s can't be resent", cmd)
+		c.completeCommand(cmd, errQueueFull)
+	}
+}
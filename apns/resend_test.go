@@ -0,0 +1,52 @@
+package apns
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWorkerHandleErrorResponse(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.Config = &ClientConfig{ResendWindowSize: 3, ResultStore: NewMemoryResultStore(10)}
+	c.commandsQueue = make(chan CommandInterface, 10)
+	c.commandErrorsQueue = make(chan CommandErrorInterface, 10)
+
+	w := &worker{id: 1, client: c}
+
+	var commands []CommandInterface
+	for i := 0; i < 4; i++ {
+		cmd := NewPushNotificationCommand(NewNotification())
+		commands = append(commands, cmd)
+		w.rememberSent(cmd)
+	}
+
+	assert.Len(w.sent, 3, "Window should be limited to configured size")
+
+	// APNS rejects the first command in window while the last one is being executed
+	failedIdentifier, _ := hex.DecodeString(commands[1].Identifier())
+	response := append([]byte{ErrorResponseCommandValue, 8}, failedIdentifier...)
+
+	err := w.handleErrorResponse(response, commands[3])
+	assert.True(isTransientError(err), "Executed command should be retried")
+	assert.Len(w.sent, 0, "Window should be cleared")
+
+	commandError := <-c.commandErrorsQueue
+	assert.Equal(commands[1], commandError.GetCommand(), "Error should be reported for the failed command")
+	assert.Equal(uint8(8), commandError.GetStatusCode(), "Error should carry APNS status")
+	assert.Len(c.commandsQueue, 1, "Command written between the failed and executed command should be resent")
+	resent := <-c.commandsQueue
+	assert.Equal(commands[2].Identifier(), resent.Identifier(), "Resent command should have the same identifier")
+	assert.NotEqual(commands[2], resent, "Completed command shouldn't be reused")
+
+	// APNS rejects the command being executed
+	w.rememberSent(commands[0])
+	w.rememberSent(commands[1])
+	failedIdentifier, _ = hex.DecodeString(commands[1].Identifier())
+	response = append([]byte{ErrorResponseCommandValue, 8}, failedIdentifier...)
+
+	err = w.handleErrorResponse(response, commands[1])
+	assert.False(isTransientError(err), "Invalid token shouldn't be retried")
+}
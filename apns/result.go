@@ -0,0 +1,125 @@
+package apns
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// ResultQueued is the status of notification waiting in the commands queue or for a retry
+	ResultQueued = "queued"
+	// ResultSent is the status of notification written to APNS without an error response
+	ResultSent = "sent"
+	// ResultFailed is the status of notification that couldn't be sent
+	ResultFailed = "failed"
+
+	// DefaultResultStoreSize is the number of most recent results kept by the default result store
+	DefaultResultStoreSize = 100000
+)
+
+// Result describes the current outcome of a notification
+type Result struct {
+	Identifier    string    `json:"identifier"`
+	CorrelationID string    `json:"correlationId,omitempty"`
+	Status        string    `json:"status"`
+	APNSStatus    uint8     `json:"apnsStatus,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Updated       time.Time `json:"updated"`
+}
+
+// ResultStore keeps outcomes of notifications so they can be queried by notification identifier
+type ResultStore interface {
+	// Put stores result replacing previous result of the same notification
+	Put(result *Result) error
+
+	// Get returns results of given notifications keyed by identifier, unknown identifiers are left out
+	Get(identifiers []string) (results map[string]*Result, err error)
+}
+
+// MemoryResultStore keeps a limited number of most recent results in memory
+type MemoryResultStore struct {
+	lock    sync.RWMutex
+	size    int
+	results map[string]*Result
+	order   []string
+	next    int
+}
+
+// NewMemoryResultStore returns an empty in-memory result store keeping at most size results. Non-positive size keeps all results.
+func NewMemoryResultStore(size int) *MemoryResultStore {
+	s := new(MemoryResultStore)
+	s.size = size
+	s.results = make(map[string]*Result)
+
+	return s
+}
+
+// Put implements ResultStore interface. Once the store is full the oldest result is evicted.
+func (s *MemoryResultStore) Put(result *Result) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.results[result.Identifier]; ok || s.size <= 0 {
+		s.results[result.Identifier] = result
+		return nil
+	}
+
+	if len(s.order) < s.size {
+		s.order = append(s.order, result.Identifier)
+	} else {
+		delete(s.results, s.order[s.next])
+		s.order[s.next] = result.Identifier
+		s.next = (s.next + 1) % s.size
+	}
+
+	s.results[result.Identifier] = result
+
+	return nil
+}
+
+// Get implements ResultStore interface
+func (s *MemoryResultStore) Get(identifiers []string) (results map[string]*Result, err error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	results = make(map[string]*Result)
+	for _, identifier := range identifiers {
+		if result, ok := s.results[identifier]; ok {
+			results[identifier] = result
+		}
+	}
+
+	return
+}
+
+// NotificationResults returns current outcomes of given notifications keyed by identifier
+func (c *Client) NotificationResults(identifiers []string) (map[string]*Result, error) {
+	return c.Config.ResultStore.Get(identifiers)
+}
+
+// recordResult stores outcome of a push notification command, other commands are ignored
+func (c *Client) recordResult(cmd CommandInterface, status string, err error) {
+	notification, ok := cmd.Data().(*Notification)
+	if !ok {
+		return
+	}
+
+	result := &Result{
+		Identifier:    notification.NotificationIdentifier,
+		CorrelationID: notification.CorrelationID,
+		Status:        status,
+		Updated:       time.Now(),
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+
+		if commandError, ok := err.(CommandErrorInterface); ok {
+			result.APNSStatus = commandError.GetStatusCode()
+		}
+	}
+
+	if storeErr := c.Config.ResultStore.Put(result); storeErr != nil {
+		logger.Errorf("Couldn't store result of %s: %s", cmd, storeErr)
+	}
+}
@@ -0,0 +1,27 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryResultStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewMemoryResultStore(2)
+
+	store.Put(&Result{Identifier: "aaaaaaaa", Status: ResultQueued})
+	store.Put(&Result{Identifier: "bbbbbbbb", Status: ResultQueued})
+	store.Put(&Result{Identifier: "aaaaaaaa", Status: ResultSent})
+
+	results, err := store.Get([]string{"aaaaaaaa", "bbbbbbbb", "cccccccc"})
+	assert.Nil(err, "Get shouldn't produce error")
+	assert.Len(results, 2, "Unknown identifiers should be left out")
+	assert.Equal(ResultSent, results["aaaaaaaa"].Status, "Result should be replaced")
+
+	store.Put(&Result{Identifier: "cccccccc", Status: ResultFailed})
+
+	results, _ = store.Get([]string{"aaaaaaaa", "bbbbbbbb", "cccccccc"})
+	assert.Len(results, 2, "Store shouldn't grow over its size")
+	assert.NotContains(results, "aaaaaaaa", "Oldest result should be evicted")
+}
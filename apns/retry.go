@@ -0,0 +1,127 @@
+package apns
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is the default number of times a command is retried after a transient failure
+	DefaultMaxRetries = 3
+
+	// DefaultRetryBackoff is the default delay before the first retry
+	DefaultRetryBackoff = time.Millisecond * 100
+
+	// DefaultRetryMaxBackoff is the default upper limit of delay between retries
+	DefaultRetryMaxBackoff = time.Second * 10
+)
+
+// transientError wraps errors after which a command may succeed if it's executed again
+type transientError struct {
+	error
+}
+
+// isTransientError reports whether the command failed because of connection problems or APNS processing error/shutdown
+func isTransientError(err error) bool {
+	switch e := err.(type) {
+	case *transientError:
+		return true
+	case *CommandError:
+		return e.statusCode == 1 || e.statusCode == 10
+	}
+
+	return false
+}
+
+// backoffDelay returns exponentially growing delay with jitter for given attempt
+func backoffDelay(attempt uint32, base, max time.Duration) time.Duration {
+	delay := base
+	for i := uint32(1); i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	// equal jitter keeps at least half of the delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryCommand schedules command for another execution if it failed transiently and has attempts left
+func (c *Client) retryCommand(cmd CommandInterface, err error) bool {
+	if !isTransientError(err) {
+		return false
+	}
+
+	c.attemptsLock.Lock()
+	attempt := c.attempts[cmd] + 1
+	if attempt > c.Config.MaxRetries {
+		c.attemptsLock.Unlock()
+		return false
+	}
+	c.attempts[cmd] = attempt
+	c.attemptsLock.Unlock()
+
+	delay := backoffDelay(attempt, c.Config.RetryBackoff, c.Config.RetryMaxBackoff)
+	logger.Warningf("Retrying %s in %s (attempt %d of %d) after error: %s", cmd, delay, attempt, c.Config.MaxRetries, err)
+
+	atomic.AddInt32(&c.pending, 1)
+	time.AfterFunc(delay, func() {
+		defer atomic.AddInt32(&c.pending, -1)
+
+		select {
+		case c.commandsQueue <- cmd:
+			break
+		default:
+			logger.Warningf("Command queue is full, %s can't be retried", cmd)
+			c.completeCommand(cmd, err)
+		}
+	})
+
+	return true
+}
+
+// completeCommand reports the final error of command, if any, and closes its errors channel
+func (c *Client) completeCommand(cmd CommandInterface, err error) {
+	c.attemptsLock.Lock()
+	delete(c.attempts, cmd)
+	c.attemptsLock.Unlock()
+
+	if err == nil {
+		c.recordResult(cmd, ResultSent, nil)
+	} else {
+		commandError, ok := err.(*CommandError)
+		if !ok {
+			commandError = NewCommandError(err, cmd)
+		}
+
+		c.recordResult(cmd, ResultFailed, commandError)
+
+		c.reportError(commandError)
+
+		select {
+		case cmd.Errors() <- commandError:
+			break
+		default:
+			break
+		}
+	}
+
+	close(cmd.Errors())
+}
+
+// reportError queues command error for client's error dispatcher
+func (c *Client) reportError(commandError CommandErrorInterface) {
+	select {
+	case c.commandErrorsQueue <- commandError:
+		break
+	default:
+		logger.Errorf("Command error queue is full, dropping error: %+v", commandError)
+	}
+}
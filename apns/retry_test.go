@@ -0,0 +1,30 @@
+package apns
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	base := time.Millisecond * 100
+	max := time.Second
+
+	for attempt, expected := range map[uint32]time.Duration{1: base, 2: base * 2, 3: base * 4, 4: base * 8, 5: max, 10: max} {
+		delay := backoffDelay(attempt, base, max)
+		assert.True(delay >= expected/2 && delay <= expected, "Delay %s of attempt %d should be between %s and %s", delay, attempt, expected/2, expected)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(isTransientError(&transientError{errors.New("connection reset by peer")}), "Connection errors should be transient")
+	assert.True(isTransientError(NewCommandErrorFromAPNSResponse([]byte{8, 1, 0, 0, 0, 1}, nil)), "Processing error should be transient")
+	assert.True(isTransientError(NewCommandErrorFromAPNSResponse([]byte{8, 10, 0, 0, 0, 1}, nil)), "Shutdown should be transient")
+	assert.False(isTransientError(NewCommandErrorFromAPNSResponse([]byte{8, 8, 0, 0, 0, 1}, nil)), "Invalid token shouldn't be transient")
+	assert.False(isTransientError(errors.New("apns/notification: Invalid payload")), "Validation errors shouldn't be transient")
+}
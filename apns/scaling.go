@@ -0,0 +1,82 @@
+package apns
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// startWorker creates a new worker connected to APNS and adds it to client's workers
+func (c *Client) startWorker() (w *worker, err error) {
+	w, err = newWorker(int(atomic.AddUint32(&workerID, 1)), c)
+	if err != nil {
+		return
+	}
+
+	c.workersLock.Lock()
+	c.workers = append(c.workers, w)
+	c.workersLock.Unlock()
+
+	return
+}
+
+// forwardCommand passes a command sent to work queue of a retired worker to another worker
+func (c *Client) forwardCommand(workQueue chan CommandInterface) {
+	cmd := <-workQueue
+	logger.Debugf("Forwarding %s of retired worker", cmd)
+	(<-c.workerQueue) <- cmd
+}
+
+// WorkerCount returns the number of workers the client should run, see SetWorkerCount
+func (c *Client) WorkerCount() uint32 {
+	return atomic.LoadUint32(&c.workerCount)
+}
+
+// SetWorkerCount grows or shrinks the pool of workers to n at runtime. New workers connect to APNS before SetWorkerCount returns,
+// retired workers finish the command they execute and close their connection afterwards. Error is returned when some of the new
+// workers couldn't connect, workers that did connect keep running.
+func (c *Client) SetWorkerCount(n uint32) error {
+	if n == 0 {
+		return errors.New("apns: Number of workers must be positive")
+	}
+
+	c.scalingLock.Lock()
+	defer c.scalingLock.Unlock()
+
+	atomic.StoreUint32(&c.workerCount, n)
+
+	c.workersLock.Lock()
+	current := uint32(len(c.workers))
+	var retired []*worker
+	if n < current {
+		retired = c.workers[n:]
+		c.workers = c.workers[:n:n]
+	}
+	c.workersLock.Unlock()
+
+	for _, w := range retired {
+		logger.Infof("Retiring worker #%d", w.id)
+		w.retireSignal <- true
+	}
+
+	var failed int
+	var err error
+	for i := current; i < n; i++ {
+		w, workerErr := c.startWorker()
+		if workerErr != nil {
+			logger.Warningf("Worker #%d couldn't be initialized: %s", w.id, workerErr)
+			failed++
+			err = workerErr
+		}
+	}
+
+	if failed > 0 {
+		return errors.New("apns: " + strconv.Itoa(failed) + " of " + strconv.Itoa(int(n-current)) + " new workers couldn't be started: " + err.Error())
+	}
+
+	if n != current {
+		logger.Infof("Scaled workers from %d to %d", current, n)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package apns
+
+import (
+	"crypto/tls"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newIdleTestWorker starts execution loop of a paused worker connected to a pipe
+func newIdleTestWorker(c *Client, id int) *worker {
+	conn, _ := net.Pipe()
+
+	w := &worker{id: id, client: c, tlsConn: tls.Client(conn, &tls.Config{InsecureSkipVerify: true})}
+	w.readySignal = make(chan bool, 1)
+	w.pauseSignal = make(chan bool, 1)
+	w.quitSignal = make(chan bool)
+	w.retireSignal = make(chan bool, 1)
+	w.workQueue = make(chan CommandInterface)
+
+	go w.executionLoopRoutine(c)
+
+	return w
+}
+
+func TestClientSetWorkerCountRetiresWorkers(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.Config = new(ClientConfig)
+	c.workerQueue = make(chan chan CommandInterface, 3)
+	c.workers = []*worker{newIdleTestWorker(c, 1), newIdleTestWorker(c, 2), newIdleTestWorker(c, 3)}
+	for _, w := range c.workers {
+		w.readySignal <- true
+	}
+	c.workerCount = 3
+
+	assert.NotNil(c.SetWorkerCount(0), "Scaling to zero workers should be refused")
+
+	assert.Nil(c.SetWorkerCount(1), "Shrinking shouldn't produce error")
+	assert.Equal(uint32(1), c.WorkerCount())
+	assert.Len(c.workers, 1, "Retired workers should be removed")
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&c.activeWorkers) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(int32(1), c.ActiveWorkers(), "Retired workers should stop")
+}
+
+func TestWorkerRestart(t *testing.T) {
+	assert := assert.New(t)
+
+	c := new(Client)
+	c.Config = &ClientConfig{APNSGatewayPort: 1}
+	w := newIdleTestWorker(c, 1)
+	w.gateway = "127.0.0.1"
+
+	// worker is paused after failed reconnection when it receives quit signal
+	w.quitSignal <- true
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&c.deadWorkers) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(int32(1), c.Stats().DeadWorkers, "Worker should be reported dead while restarting")
+	assert.Equal(int32(0), c.ActiveWorkers(), "Dead worker shouldn't count as active")
+
+	w.retireSignal <- true
+
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&c.deadWorkers) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(int32(0), c.Stats().DeadWorkers, "Retired worker shouldn't be restarted")
+}
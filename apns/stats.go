@@ -0,0 +1,125 @@
+package apns
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// WorkersPerProcWarningThreshold is the number of workers per GOMAXPROCS above which worker count is reported as excessive
+	WorkersPerProcWarningThreshold = 64
+
+	// GCPauseWarningThreshold is the GC pause length above which pauses are reported as impacting notification throughput
+	GCPauseWarningThreshold = time.Millisecond * 10
+
+	// QueueOccupancyWarningThreshold is the queue occupancy above which queue is reported as saturated
+	QueueOccupancyWarningThreshold = 0.9
+
+	recentGCPauses = 10
+)
+
+// RuntimeStats describes go runtime state relevant for tuning of workers and queue sizes
+type RuntimeStats struct {
+	Goroutines      int       `json:"goroutines"`
+	GOMAXPROCS      int       `json:"gomaxprocs"`
+	NumCPU          int       `json:"numCPU"`
+	NumGC           uint32    `json:"numGC"`
+	GCPauseTotalMs  float64   `json:"gcPauseTotalMs"`
+	GCPauseRecentMs []float64 `json:"gcPauseRecentMs"`
+	GCCPUFraction   float64   `json:"gcCPUFraction"`
+	HeapAllocBytes  uint64    `json:"heapAllocBytes"`
+}
+
+// Stats holds client's worker, queue and runtime statistics
+type Stats struct {
+	Workers           int32                 `json:"workers"`
+	ConfiguredWorkers uint32                `json:"configuredWorkers"`
+	StalledWorkers    uint64                `json:"stalledWorkers"`
+	GatewayCloses     uint64                `json:"gatewayCloses"`
+	DeadWorkers       int32                 `json:"deadWorkers"`
+	WorkerRestarts    uint64                `json:"workerRestarts"`
+	IdleReconnects    uint64                `json:"idleReconnects"`
+	Queue             QueueState            `json:"queue"`
+	Maintenance       bool                  `json:"maintenance"`
+	Stores            map[string]StoreStats `json:"stores,omitempty"`
+	Runtime           RuntimeStats          `json:"runtime"`
+	LastReload        *ReloadReport         `json:"lastReload,omitempty"`
+	Warnings          []string              `json:"warnings,omitempty"`
+}
+
+// Stats returns current statistics of the client
+func (c *Client) Stats() *Stats {
+	stats := new(Stats)
+	stats.Workers = c.ActiveWorkers()
+	stats.ConfiguredWorkers = c.WorkerCount()
+	stats.StalledWorkers = atomic.LoadUint64(&c.stalledWorkers)
+	stats.GatewayCloses = atomic.LoadUint64(&c.gatewayCloses)
+	stats.DeadWorkers = atomic.LoadInt32(&c.deadWorkers)
+	stats.WorkerRestarts = atomic.LoadUint64(&c.workerRestarts)
+	stats.IdleReconnects = atomic.LoadUint64(&c.idleReconnects)
+	stats.Queue = QueueState{Length: len(c.commandsQueue), Capacity: cap(c.commandsQueue)}
+	stats.Maintenance = c.Maintenance().Active
+	stats.Stores = c.storeStats()
+	stats.Runtime = readRuntimeStats()
+	stats.LastReload = c.LastReload()
+
+	if int(stats.Workers) > WorkersPerProcWarningThreshold*stats.Runtime.GOMAXPROCS {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%d workers exceed %d per GOMAXPROCS (%d), consider lowering --workers", stats.Workers, WorkersPerProcWarningThreshold, stats.Runtime.GOMAXPROCS))
+	}
+
+	if stats.Workers < int32(stats.ConfiguredWorkers) {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("only %d of %d configured workers are running", stats.Workers, stats.ConfiguredWorkers))
+	}
+
+	if stats.DeadWorkers > 0 {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("%d workers lost their connection and are being restarted", stats.DeadWorkers))
+	}
+
+	if stats.Maintenance {
+		stats.Warnings = append(stats.Warnings, "APNS maintenance is in progress, notifications are buffered in the queue")
+	}
+
+	if stats.Queue.Occupancy() >= QueueOccupancyWarningThreshold {
+		stats.Warnings = append(stats.Warnings, fmt.Sprintf("queue is %.0f%% full, consider raising --workers or --max-notifications", stats.Queue.Occupancy()*100))
+	}
+
+	for _, pause := range stats.Runtime.GCPauseRecentMs {
+		if pause >= float64(GCPauseWarningThreshold)/float64(time.Millisecond) {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("recent GC pause of %.2fms exceeds %s, consider smaller queue size", pause, GCPauseWarningThreshold))
+			break
+		}
+	}
+
+	return stats
+}
+
+// ActiveWorkers returns the number of running workers
+func (c *Client) ActiveWorkers() int32 {
+	return atomic.LoadInt32(&c.activeWorkers)
+}
+
+func readRuntimeStats() RuntimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		GOMAXPROCS:     runtime.GOMAXPROCS(0),
+		NumCPU:         runtime.NumCPU(),
+		NumGC:          memStats.NumGC,
+		GCPauseTotalMs: float64(memStats.PauseTotalNs) / float64(time.Millisecond),
+		GCCPUFraction:  memStats.GCCPUFraction,
+		HeapAllocBytes: memStats.HeapAlloc,
+	}
+
+	// PauseNs is a circular buffer, most recent pause is at (NumGC+255)%256
+	stats.GCPauseRecentMs = make([]float64, 0, recentGCPauses)
+	for i := uint32(0); i < recentGCPauses && i < memStats.NumGC; i++ {
+		pause := memStats.PauseNs[(memStats.NumGC-i+255)%256]
+		stats.GCPauseRecentMs = append(stats.GCPauseRecentMs, float64(pause)/float64(time.Millisecond))
+	}
+
+	return stats
+}
@@ -0,0 +1,44 @@
+package apns
+
+import (
+	"time"
+)
+
+// StoreStats describes size and housekeeping of a persistent store
+type StoreStats struct {
+	// SizeBytes is the size of store's data on disk
+	SizeBytes int64 `json:"sizeBytes"`
+
+	// Entries is the number of stored entries
+	Entries int `json:"entries"`
+
+	// Expired is the number of entries removed after their TTL passed
+	Expired uint64 `json:"expired"`
+
+	Compactions    uint64    `json:"compactions"`
+	LastCompaction time.Time `json:"lastCompaction,omitempty"`
+}
+
+// StoreStatsReporter is implemented by feedback and result stores able to report their size, it is reported by Client.Stats
+type StoreStatsReporter interface {
+	StoreStats() StoreStats
+}
+
+// storeStats returns stats of client's stores implementing StoreStatsReporter keyed by "feedback" and "result"
+func (c *Client) storeStats() (stats map[string]StoreStats) {
+	stores := map[string]interface{}{
+		"feedback": c.Config.FeedbackStore,
+		"result":   c.Config.ResultStore,
+	}
+
+	for name, store := range stores {
+		if reporter, ok := store.(StoreStatsReporter); ok {
+			if stats == nil {
+				stats = make(map[string]StoreStats)
+			}
+			stats[name] = reporter.StoreStats()
+		}
+	}
+
+	return
+}
@@ -0,0 +1,40 @@
+package apns
+
+// ErrorSubscriptionQueueSize is the number of command errors buffered for each subscriber
+const ErrorSubscriptionQueueSize = 100
+
+// SubscribeErrors returns a channel receiving a copy of every command error and a function that cancels the subscription.
+// Errors are dropped for subscribers that don't keep up.
+func (c *Client) SubscribeErrors() (<-chan CommandErrorInterface, func()) {
+	ch := make(chan CommandErrorInterface, ErrorSubscriptionQueueSize)
+
+	c.errorSubscribersLock.Lock()
+	c.errorSubscribers[ch] = true
+	c.errorSubscribersLock.Unlock()
+
+	unsubscribe := func() {
+		c.errorSubscribersLock.Lock()
+		defer c.errorSubscribersLock.Unlock()
+
+		if c.errorSubscribers[ch] {
+			delete(c.errorSubscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (c *Client) publishError(commandError CommandErrorInterface) {
+	c.errorSubscribersLock.Lock()
+	defer c.errorSubscribersLock.Unlock()
+
+	for ch := range c.errorSubscribers {
+		select {
+		case ch <- commandError:
+			break
+		default:
+			logger.Warningf("Error subscriber queue is full, dropping error: %s", commandError)
+		}
+	}
+}
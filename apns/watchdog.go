@@ -0,0 +1,50 @@
+package apns
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStallTimeout is the default time a worker may execute a single command before watchdog aborts it
+const DefaultStallTimeout = time.Second * 30
+
+// watchWorkers checks workers for stalled commands twice per StallTimeout
+func (c *Client) watchWorkers() {
+	ticker := time.NewTicker(c.Config.StallTimeout / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		c.checkStalledWorkers()
+	}
+}
+
+// checkStalledWorkers closes connections of workers executing a single command for longer than StallTimeout.
+// Blocked write or read then fails, command is retried and worker reconnects.
+func (c *Client) checkStalledWorkers() {
+	c.workersLock.Lock()
+	workers := c.workers
+	c.workersLock.Unlock()
+
+	now := time.Now().UnixNano()
+
+	for _, w := range workers {
+		busySince := atomic.LoadInt64(&w.busySince)
+		if busySince == 0 || time.Duration(now-busySince) < c.Config.StallTimeout {
+			continue
+		}
+
+		// reset so the same stall isn't reported again
+		if !atomic.CompareAndSwapInt64(&w.busySince, busySince, 0) {
+			continue
+		}
+
+		atomic.AddUint64(&c.stalledWorkers, 1)
+		logger.Warningf("Worker #%d is stuck executing a command for %s, closing its connection", w.id, time.Duration(now-busySince))
+
+		w.connLock.Lock()
+		if w.conn != nil {
+			w.conn.Close()
+		}
+		w.connLock.Unlock()
+	}
+}
@@ -0,0 +1,31 @@
+package apns
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientCheckStalledWorkers(t *testing.T) {
+	assert := assert.New(t)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	c := new(Client)
+	c.Config = &ClientConfig{StallTimeout: time.Second}
+
+	idle := &worker{id: 1, client: c}
+	stalled := &worker{id: 2, client: c, conn: conn, busySince: time.Now().Add(-time.Minute).UnixNano()}
+	c.workers = []*worker{idle, stalled}
+
+	c.checkStalledWorkers()
+	c.checkStalledWorkers()
+
+	assert.Equal(uint64(1), c.Stats().StalledWorkers, "Stall should be counted once")
+	assert.Equal(int64(0), stalled.busySince, "Stalled worker should be reset")
+
+	_, err := conn.Write([]byte{0})
+	assert.NotNil(err, "Connection of stalled worker should be closed")
+}
@@ -0,0 +1,73 @@
+package apns
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// WebhookTimeout is the maximum time spent delivering a single webhook request
+const WebhookTimeout = time.Second * 10
+
+var webhookClient = &http.Client{Timeout: WebhookTimeout}
+
+// FailedNotification is the data POSTed to failure webhook when a notification couldn't be sent
+type FailedNotification struct {
+	Identifier    string `json:"identifier"`
+	CorrelationID string `json:"correlationId,omitempty"`
+	DeviceToken   string `json:"deviceToken,omitempty"`
+	Status        uint8  `json:"status,omitempty"`
+	Error         string `json:"error"`
+}
+
+// NewFailedNotification describes the notification the command error belongs to
+func NewFailedNotification(commandError CommandErrorInterface) *FailedNotification {
+	failure := &FailedNotification{
+		Status: commandError.GetStatusCode(),
+		Error:  commandError.Error(),
+	}
+
+	if commandError.GetCommand() != nil {
+		failure.Identifier = commandError.GetCommand().Identifier()
+
+		if notification, ok := commandError.GetCommand().Data().(*Notification); ok {
+			failure.DeviceToken = notification.DeviceToken
+			failure.CorrelationID = notification.CorrelationID
+		}
+	}
+
+	return failure
+}
+
+func postWebhook(url string, data interface{}) (err error) {
+	var body []byte
+	body, err = json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	var rsp *http.Response
+	rsp, err = webhookClient.Post(url, "application/json; charset=utf8", bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode >= 300 {
+		logger.Warningf("Webhook %s responded with %s", url, rsp.Status)
+	}
+
+	return
+}
+
+func (c *Client) notifyFailureWebhook(commandError CommandErrorInterface) {
+	if c.Config.FailureWebhookURL == "" || commandError.GetCommand() == nil {
+		return
+	}
+
+	err := postWebhook(c.Config.FailureWebhookURL, NewFailedNotification(commandError))
+	if err != nil {
+		logger.Errorf("Failure webhook for %s couldn't be delivered: %s", commandError.GetCommand(), err)
+	}
+}
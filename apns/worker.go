@@ -4,9 +4,11 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"github.com/spf13/pflag"
 	"io"
 	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -28,39 +30,51 @@ const (
 
 	// FeedbackGatewayPort ...
 	FeedbackGatewayPort uint16 = 2196
-)
 
-var (
-	apnsGatewayProduction     = APNSGatewayProduction
-	apnsGatewaySandbox        = APNSGatewaySandbox
-	apnsGatewayPort           = APNSGatewayPort
-	feedbackGatewayProduction = FeedbackGatewayProduction
-	feedbackGatewaySandbox    = FeedbackGatewaySandbox
-	feedbackGatewayPort       = FeedbackGatewayPort
-)
+	// workerRestartMinBackoff is the delay before the first attempt to restart a worker whose connection couldn't be reestablished
+	workerRestartMinBackoff = time.Second
 
-func setupWorkerCommandLineFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&apnsGatewayProduction, "apns-gate-production", apnsGatewayProduction, "FQDN of Apple's APNS production gateway.")
-	fs.StringVar(&apnsGatewaySandbox, "apns-gate-sandbox", apnsGatewaySandbox, "FQDN of Apple's APNS sandbox gateway.")
-	fs.StringVar(&feedbackGatewayProduction, "feedback-gate-production", feedbackGatewayProduction, "FQDN of Apple's Feedback service production gateway.")
-	fs.StringVar(&feedbackGatewaySandbox, "feedback-gate-sandbox", feedbackGatewaySandbox, "FQDN of Apple's Feedback service sandbox gateway.")
-	fs.Uint16Var(&apnsGatewayPort, "apns-gate-port", apnsGatewayPort, "Apple's APNS port number")
-	fs.Uint16Var(&feedbackGatewayPort, "feedback-gate-port", feedbackGatewayPort, "Apple's Feedback service port number")
-}
+	// workerRestartMaxBackoff is the maximum delay between attempts to restart a worker
+	workerRestartMaxBackoff = time.Minute
+)
 
 // worker ...
 type worker struct {
-	id int
+	id     int
+	client *Client
 
+	gateway   string
 	tlsConfig *tls.Config
 	tlsConn   *tls.Conn
 
+	// conn is the underlying connection of tlsConn, watchdog closes it to abort a stalled command
+	conn     net.Conn
+	connLock sync.Mutex
+
+	// renewConnection is set when certificate was reloaded and connection should be established again with the new one
+	renewConnection int32
+
+	// staleConnection is set when connection probe detected gateway closing connections, worker reconnects before its next command
+	staleConnection int32
+
+	// lastActive is the unix time in nanoseconds of connecting or finishing the last command
+	lastActive int64
+
+	// busySince is the unix time in nanoseconds the command being executed was received at, zero when idle
+	busySince int64
+
 	readySignal chan bool
 	pauseSignal chan bool
 	quitSignal  chan bool
 	errorSignal chan CommandErrorInterface
 
+	// retireSignal stops the worker once it finishes its current command
+	retireSignal chan bool
+
 	workQueue chan CommandInterface
+
+	// sent holds recently written commands in order of writing
+	sent []CommandInterface
 }
 
 // newWorker creates, initializes and returns new worker
@@ -68,11 +82,13 @@ func newWorker(workerID int, c *Client) (w *worker, err error) {
 	w = new(worker)
 
 	w.id = workerID
+	w.client = c
 
 	w.readySignal = make(chan bool, 1)
 	w.pauseSignal = make(chan bool, 1)
 	w.quitSignal = make(chan bool)
 	w.errorSignal = make(chan CommandErrorInterface)
+	w.retireSignal = make(chan bool, 1)
 
 	w.workQueue = make(chan CommandInterface)
 
@@ -86,18 +102,12 @@ func (w *worker) init(c *Client) (err error) {
 
 	var gateway string
 	if c.isProdEnv() {
-		gateway = apnsGatewayProduction
+		gateway = c.Config.APNSGatewayProduction
 	} else {
-		gateway = apnsGatewaySandbox
+		gateway = c.Config.APNSGatewaySandbox
 	}
 
-	config := &tls.Config{
-		ServerName:   gateway,
-		Certificates: []tls.Certificate{c.certificate},
-	}
-
-	logger.Debugf("Worker #%d TLS config %+v", w.id, config)
-	w.tlsConfig = config
+	w.gateway = gateway
 
 	err = w.connect()
 
@@ -131,12 +141,19 @@ func (w *worker) init(c *Client) (err error) {
 func (w *worker) connect() (err error) {
 	var conn net.Conn
 
+	// certificate may have been reloaded since the last connection
+	atomic.StoreInt32(&w.renewConnection, 0)
+	w.tlsConfig = &tls.Config{
+		ServerName:   w.gateway,
+		Certificates: []tls.Certificate{w.client.currentCertificate()},
+	}
+
 	dialer := &net.Dialer{}
 	dialer.KeepAlive = time.Second * 10
 
-	logger.Infof("Worker #%d connecting to %s:%d", w.id, w.tlsConfig.ServerName, apnsGatewayPort)
+	logger.Infof("Worker #%d connecting to %s:%d", w.id, w.tlsConfig.ServerName, w.client.Config.APNSGatewayPort)
 
-	conn, err = dialer.Dial("tcp", fmt.Sprintf("%s:%d", w.tlsConfig.ServerName, apnsGatewayPort))
+	conn, err = dialer.Dial("tcp", net.JoinHostPort(w.tlsConfig.ServerName, strconv.Itoa(int(w.client.Config.APNSGatewayPort))))
 	if err != nil {
 		// fmt.Println("worker: error dialing ...", err)
 		return
@@ -144,6 +161,10 @@ func (w *worker) connect() (err error) {
 
 	logger.Debugf("Worker #%d connected to %s", w.id, conn.RemoteAddr().String())
 
+	w.connLock.Lock()
+	w.conn = conn
+	w.connLock.Unlock()
+
 	w.tlsConn = tls.Client(conn, w.tlsConfig)
 	err = w.tlsConn.Handshake()
 
@@ -152,11 +173,14 @@ func (w *worker) connect() (err error) {
 		return
 	}
 
+	atomic.StoreInt64(&w.lastActive, time.Now().UnixNano())
+
 	return
 }
 
 func (w *worker) disconnect() {
 	logger.Warningf("Worker #%d disconnecting", w.id)
+	w.forgetSent()
 	w.tlsConn.Close()
 }
 
@@ -190,11 +214,20 @@ func (w *worker) executeCommand(cmd CommandInterface) (err error) {
 
 	logger.Infof("Worker #%d processing %s", w.id, cmd)
 
+	if notification, ok := cmd.Data().(*Notification); ok {
+		err = w.client.validateTopic(notification.Topic)
+		if err != nil {
+			return
+		}
+	}
+
 	cmdBytes, err = cmd.Bytes()
 	if err != nil {
 		return
 	}
 
+	w.client.dumpOutgoingFrame(fmt.Sprintf("Worker #%d", w.id), cmdBytes)
+
 	// write data to APNS
 	logger.Debugf("Worker #%d writing %+v bytes", w.id, len(cmdBytes))
 	// w.tlsConn.SetWriteDeadline(time.Now().Add(time.Millisecond * 1000))
@@ -207,12 +240,16 @@ func (w *worker) executeCommand(cmd CommandInterface) (err error) {
 		if err == io.EOF {
 			logger.Warningf("Worker #%d connection appears to be closed by peer", w.id)
 			err = errors.New("apns/worker: Error writing data. Connection appears to be closed by peer")
-			w.reconnect()
 		}
 
+		err = &transientError{err}
+		w.reconnect()
+
 		return
 	}
 
+	w.rememberSent(cmd)
+
 	// read response from APNS
 	w.tlsConn.SetReadDeadline(time.Now().Add(time.Millisecond * 500))
 	read, err = w.tlsConn.Read(responseBytes)
@@ -223,80 +260,104 @@ func (w *worker) executeCommand(cmd CommandInterface) (err error) {
 
 		if err == io.EOF {
 			logger.Warningf("Worker #%d connection closed by peer", w.id)
+			err = errors.New("apns/worker: Connection was closed by peer after reading data")
 		}
 
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 			err = nil
+		} else {
+			err = &transientError{err}
 		}
 	}
 
 	if read > 0 {
 		logger.Warningf("Worker #%d received error response", w.id)
+		w.client.dumpErrorResponseFrame(fmt.Sprintf("Worker #%d", w.id), responseBytes[:read])
 
-		commandError := NewCommandErrorFromAPNSResponse(responseBytes, cmd)
-		w.errorSignal <- commandError
-
-		select {
-		case cmd.Errors() <- commandError:
-			break
-		default:
-			break
-		}
+		err = w.handleErrorResponse(responseBytes[:read], cmd)
 	}
 
-	if read > 0 || err == io.EOF {
+	if err != nil {
 		w.reconnect()
-
-		if err == io.EOF {
-			err = errors.New("apns/worker: Connection was closed by peer after reading data")
-		}
 	}
 
 	return
 }
 
 func (w *worker) executionLoopRoutine(c *Client) {
+	atomic.AddInt32(&c.activeWorkers, 1)
+	defer atomic.AddInt32(&c.activeWorkers, -1)
 	defer w.disconnect()
 
+	var healthCheck <-chan time.Time
+	if c.Config.HealthCheckInterval > 0 {
+		ticker := time.NewTicker(c.Config.HealthCheckInterval)
+		defer ticker.Stop()
+		healthCheck = ticker.C
+	}
+
 	for {
 		select {
 		case <-w.readySignal:
 			logger.Debugf("Worker #%d ready", w.id)
 
-			c.workerQueue <- w.workQueue
+			select {
+			case c.workerQueue <- w.workQueue:
+			case <-w.retireSignal:
+				logger.Infof("Worker #%d retired", w.id)
+				return
+			}
 			logger.Debugf("Worker #%d added itself to worker queue", w.id)
 			logger.Infof("Worker #%d waiting for commands", w.id)
 
-			select {
-			case command := <-w.workQueue:
-				startTime := time.Now()
-				err := w.executeCommand(command)
-				endTime := time.Now()
+			var command CommandInterface
+			for command == nil {
+				select {
+				case <-w.retireSignal:
+					// work queue of this worker may still be picked from worker queue
+					go c.forwardCommand(w.workQueue)
+					logger.Infof("Worker #%d retired", w.id)
+					return
 
-				logger.Infof("Worker #%d processed %s in %s", w.id, command, endTime.Sub(startTime))
+				case <-healthCheck:
+					w.checkConnection()
 
-				if err != nil {
-					commandError := NewCommandError(err, command)
-					w.errorSignal <- commandError
+				case command = <-w.workQueue:
+				}
+			}
 
-					select {
-					case command.Errors() <- commandError:
-						break
-					default:
-						break
-					}
+			startTime := time.Now()
+			atomic.StoreInt64(&w.busySince, startTime.UnixNano())
+			atomic.AddInt32(&c.pending, -1)
+			err := w.refreshStaleConnection()
+			if err != nil {
+				err = &transientError{err}
+				w.reconnect()
+			} else {
+				err = w.executeCommand(command)
+			}
+			atomic.StoreInt64(&w.busySince, 0)
+			endTime := time.Now()
+			atomic.StoreInt64(&w.lastActive, endTime.UnixNano())
 
-				}
+			logger.Infof("Worker #%d processed %s in %s", w.id, command, endTime.Sub(startTime))
 
-				select {
-				case <-w.pauseSignal:
-					logger.Warningf("Worker #%d received pause signal", w.id)
-					break
-				default:
-					w.readySignal <- true
-				}
+			if err == nil || !c.retryCommand(command, err) {
+				c.completeCommand(command, err)
+			}
 
-				close(command.Errors())
+			// failed command already triggered reconnection
+			if err == nil && atomic.CompareAndSwapInt32(&w.renewConnection, 1, 0) {
+				logger.Infof("Worker #%d reconnecting to use reloaded certificate", w.id)
+				w.reconnect()
+			}
+
+			select {
+			case <-w.pauseSignal:
+				logger.Warningf("Worker #%d received pause signal", w.id)
+				break
+			default:
+				w.readySignal <- true
 			}
 
 			break
@@ -306,9 +367,46 @@ func (w *worker) executionLoopRoutine(c *Client) {
 			break
 
 		case <-w.quitSignal:
-			// TODO: Restart worker!
-			// defer w.restart()
-			return
+			if !w.restart(c) {
+				return
+			}
+		}
+	}
+}
+
+// restart connects again with growing backoff after reconnection failed. Worker doesn't count as active meanwhile.
+// It returns false when worker was retired before it could connect.
+func (w *worker) restart(c *Client) bool {
+	atomic.AddInt32(&c.activeWorkers, -1)
+	atomic.AddInt32(&c.deadWorkers, 1)
+	defer atomic.AddInt32(&c.activeWorkers, 1)
+	defer atomic.AddInt32(&c.deadWorkers, -1)
+
+	backoff := workerRestartMinBackoff
+	for attempt := 1; ; attempt++ {
+		logger.Warningf("Worker #%d is down, restarting in %s (attempt #%d)", w.id, backoff, attempt)
+
+		select {
+		case <-time.After(backoff):
+		case <-w.retireSignal:
+			logger.Infof("Worker #%d retired while down", w.id)
+			return false
+		}
+
+		w.tlsConn.Close()
+		err := w.connect()
+		if err == nil {
+			atomic.AddUint64(&c.workerRestarts, 1)
+			logger.Infof("Worker #%d restarted", w.id)
+			w.readySignal <- true
+			return true
+		}
+
+		logger.Warningf("Worker #%d couldn't be restarted: %s", w.id, err)
+
+		backoff *= 2
+		if backoff > workerRestartMaxBackoff {
+			backoff = workerRestartMaxBackoff
 		}
 	}
 }
@@ -0,0 +1,214 @@
+// Package apnsms runs the APNS microservice (apns clients and HTTP API of package server) inside another go program.
+//
+// It wires together what the apns binary does in its main function:
+//
+//	config := apnsms.NewConfig()
+//	config.Client.CertificateFile = "/path/to/cert.pem"
+//	config.Client.CertificatePrivateKeyFile = "/path/to/key.pem"
+//
+//	err := apnsms.Run(ctx, config)
+//
+// Run serves HTTP API until ctx is done, then stops accepting requests and waits for queued notifications to be sent.
+package apnsms
+
+import (
+	"context"
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/andrejbaran/apns-ms/server"
+	"net/http"
+	"time"
+)
+
+// DefaultShutdownTimeout is the default time given to in-flight requests and queued notifications on shutdown
+const DefaultShutdownTimeout = time.Second * 30
+
+// Config holds configuration of all parts of the microservice
+type Config struct {
+	// Client is the configuration of the default client
+	Client *apns.ClientConfig
+
+	// Apps are configurations of additional applications keyed by app identifier, see server.Config.Apps
+	Apps map[string]*apns.ClientConfig
+
+	// Server is the configuration of HTTP API
+	Server *server.Config
+
+	// ShutdownTimeout is the time given to in-flight requests and queued notifications on shutdown
+	ShutdownTimeout time.Duration
+}
+
+// NewConfig returns new config with default values
+func NewConfig() (config *Config) {
+	config = new(Config)
+	config.Client = apns.NewClientConfig()
+	config.Apps = make(map[string]*apns.ClientConfig)
+	config.Server = server.NewConfig()
+	config.ShutdownTimeout = DefaultShutdownTimeout
+
+	return
+}
+
+// Service is the running microservice
+type Service struct {
+	Config *Config
+
+	// Client is the default client
+	Client *apns.Client
+
+	// Apps are clients of additional applications keyed by app identifier
+	Apps map[string]*apns.Client
+
+	handler http.Handler
+}
+
+// New creates clients of all applications and HTTP API handler. Application clients share the result store of the default client.
+func New(config *Config) (s *Service, err error) {
+	s = new(Service)
+	s.Config = config
+	s.Apps = make(map[string]*apns.Client)
+
+	s.Client, err = apns.NewClient(config.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	for appID, appConfig := range config.Apps {
+		if appConfig.ResultStore == nil {
+			appConfig.ResultStore = config.Client.ResultStore
+		}
+
+		s.Apps[appID], err = apns.NewClient(appConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config.Server.Apps = s.Apps
+	s.handler = server.NewServeMux(s.Client, config.Server)
+
+	// devices reported by Feedback service won't receive audience notifications anymore
+	for _, c := range s.Clients() {
+		c.OnFeedback(func(rsp *apns.FeedbackResponse) {
+			for _, device := range rsp.Devices {
+				config.Server.Registry.Unregister(device.DeviceToken)
+			}
+		})
+	}
+
+	return
+}
+
+// Clients returns the default client followed by clients of all applications
+func (s *Service) Clients() []*apns.Client {
+	clients := []*apns.Client{s.Client}
+	for _, c := range s.Apps {
+		clients = append(clients, c)
+	}
+
+	return clients
+}
+
+// Handler returns HTTP API handler, useful for serving the API from an existing HTTP server
+func (s *Service) Handler() http.Handler {
+	return s.handler
+}
+
+// ReloadCertificates reloads certificates of all clients, it returns the first error encountered
+func (s *Service) ReloadCertificates() (err error) {
+	for _, c := range s.Clients() {
+		if reloadErr := c.ReloadCertificate(); reloadErr != nil && err == nil {
+			err = reloadErr
+		}
+	}
+
+	return
+}
+
+// Reload compares config with the running configuration and applies what can be applied without restart, i.e. certificates of all clients.
+// The combined report of all changes is kept by the default client (see apns.Stats), reports of application clients are prefixed with
+// "Apps.{appId}.", HTTP server settings with "Server.". It returns the first error encountered.
+func (s *Service) Reload(config *Config) (report *apns.ReloadReport, err error) {
+	var changes []apns.ConfigChange
+
+	changes = append(changes, apns.DiffConfig("Server.", s.Config.Server, config.Server, nil)...)
+
+	if config.ShutdownTimeout != s.Config.ShutdownTimeout {
+		changes = append(changes, apns.ConfigChange{
+			Setting:         "ShutdownTimeout",
+			Old:             s.Config.ShutdownTimeout.String(),
+			New:             config.ShutdownTimeout.String(),
+			RequiresRestart: true,
+		})
+	}
+
+	for appID := range s.Apps {
+		if _, ok := config.Apps[appID]; !ok {
+			changes = append(changes, apns.ConfigChange{Setting: "Apps." + appID, Old: appID, RequiresRestart: true})
+		}
+	}
+
+	for appID, appConfig := range config.Apps {
+		c, ok := s.Apps[appID]
+		if !ok {
+			changes = append(changes, apns.ConfigChange{Setting: "Apps." + appID, New: appID, RequiresRestart: true})
+			continue
+		}
+
+		appReport, appErr := c.Reload(appConfig)
+		if appErr != nil && err == nil {
+			err = appErr
+		}
+
+		for _, change := range appReport.Changes {
+			change.Setting = "Apps." + appID + "." + change.Setting
+			changes = append(changes, change)
+		}
+	}
+
+	report, clientErr := s.Client.Reload(config.Client, changes...)
+	if clientErr != nil && err == nil {
+		err = clientErr
+	}
+
+	return
+}
+
+// Run serves HTTP API on the configured address until ctx is done. It then shuts HTTP server down and waits for queued notifications
+// of all clients to be sent, both limited by ShutdownTimeout.
+func (s *Service) Run(ctx context.Context) error {
+	httpServer := &http.Server{Addr: s.Config.Server.Addr(), Handler: s.handler}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.Config.ShutdownTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+
+	for _, c := range s.Clients() {
+		if drainErr := c.Drain(shutdownCtx); drainErr != nil && err == nil {
+			err = drainErr
+		}
+	}
+
+	return err
+}
+
+// Run creates the service and runs it until ctx is done, see Service.Run
+func Run(ctx context.Context, config *Config) error {
+	s, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	return s.Run(ctx)
+}
@@ -0,0 +1,196 @@
+package audience
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Expression selects devices by their tags
+type Expression interface {
+	// Matches reports whether a device with given tags is selected by the expression
+	Matches(tags map[string]bool) bool
+	String() string
+}
+
+type tagExpression string
+
+func (e tagExpression) Matches(tags map[string]bool) bool {
+	return tags[string(e)]
+}
+
+func (e tagExpression) String() string {
+	return string(e)
+}
+
+type notExpression struct {
+	operand Expression
+}
+
+func (e *notExpression) Matches(tags map[string]bool) bool {
+	return !e.operand.Matches(tags)
+}
+
+func (e *notExpression) String() string {
+	return "NOT " + e.operand.String()
+}
+
+type binaryExpression struct {
+	operator    string
+	left, right Expression
+}
+
+func (e *binaryExpression) Matches(tags map[string]bool) bool {
+	if e.operator == "AND" {
+		return e.left.Matches(tags) && e.right.Matches(tags)
+	}
+
+	return e.left.Matches(tags) || e.right.Matches(tags)
+}
+
+func (e *binaryExpression) String() string {
+	return "(" + e.left.String() + " " + e.operator + " " + e.right.String() + ")"
+}
+
+// ParseExpression parses tag expression like "country:DE AND (app:foo OR NOT beta)".
+// Tags are words without whitespace and parentheses, operators are AND, OR and NOT (in order of increasing precedence).
+func ParseExpression(expression string) (Expression, error) {
+	p := &parser{tokens: tokenize(expression)}
+
+	if len(p.tokens) == 0 {
+		return nil, errors.New("audience: Expression is empty")
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.position < len(p.tokens) {
+		return nil, errors.New("audience: Unexpected \"" + p.tokens[p.position] + "\" in expression")
+	}
+
+	return e, nil
+}
+
+func tokenize(expression string) (tokens []string) {
+	var token []rune
+
+	flush := func() {
+		if len(token) > 0 {
+			tokens = append(tokens, string(token))
+			token = token[:0]
+		}
+	}
+
+	for _, r := range expression {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			token = append(token, r)
+		}
+	}
+	flush()
+
+	return
+}
+
+type parser struct {
+	tokens   []string
+	position int
+}
+
+func (p *parser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+
+	return ""
+}
+
+func (p *parser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.ToUpper(p.peek()) == "OR" {
+		p.position++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryExpression{operator: "OR", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for strings.ToUpper(p.peek()) == "AND" {
+		p.position++
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryExpression{operator: "AND", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expression, error) {
+	if strings.ToUpper(p.peek()) == "NOT" {
+		p.position++
+
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &notExpression{operand: operand}, nil
+	}
+
+	return p.parseOperand()
+}
+
+func (p *parser) parseOperand() (Expression, error) {
+	token := p.peek()
+	p.position++
+
+	switch strings.ToUpper(token) {
+	case "":
+		return nil, errors.New("audience: Unexpected end of expression")
+
+	case "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, errors.New("audience: Missing \")\" in expression")
+		}
+		p.position++
+
+		return e, nil
+
+	case ")", "AND", "OR":
+		return nil, errors.New("audience: Unexpected \"" + token + "\" in expression")
+	}
+
+	return tagExpression(token), nil
+}
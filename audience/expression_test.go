@@ -0,0 +1,46 @@
+package audience
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	assert := assert.New(t)
+
+	e, err := ParseExpression("country:DE AND (app:foo OR app:bar) AND NOT beta")
+	assert.Nil(err, "Expression should be valid")
+
+	assert.True(e.Matches(map[string]bool{"country:DE": true, "app:foo": true}), "Device should match")
+	assert.True(e.Matches(map[string]bool{"country:DE": true, "app:bar": true}), "Device should match")
+	assert.False(e.Matches(map[string]bool{"country:DE": true, "app:foo": true, "beta": true}), "Negated tag should exclude device")
+	assert.False(e.Matches(map[string]bool{"country:AT": true, "app:foo": true}), "All AND operands should match")
+
+	e, err = ParseExpression("a OR b AND c")
+	assert.Nil(err, "Expression should be valid")
+	assert.True(e.Matches(map[string]bool{"a": true}), "AND should take precedence over OR")
+
+	for _, invalid := range []string{"", "a AND", "(a OR b", "a b", "OR a", "a)"} {
+		_, err = ParseExpression(invalid)
+		assert.NotNil(err, "Expression \"%s\" should be invalid", invalid)
+	}
+}
+
+func TestMemoryRegistryMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	r := NewMemoryRegistry()
+	r.Register(&Device{DeviceToken: "a", Tags: []string{"country:DE", "app:foo"}})
+	r.Register(&Device{DeviceToken: "b", Tags: []string{"country:AT", "app:foo"}})
+
+	e, _ := ParseExpression("app:foo")
+	tokens, err := r.Match(e)
+	assert.Nil(err, "Match shouldn't produce error")
+	assert.Len(tokens, 2, "Both devices should match")
+
+	r.Register(&Device{DeviceToken: "a", Tags: []string{"country:DE"}})
+	r.Unregister("b")
+
+	tokens, _ = r.Match(e)
+	assert.Len(tokens, 0, "Re-registered tags should replace previous ones")
+}
@@ -0,0 +1,146 @@
+package audience
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/andrejbaran/apns-ms/apns"
+	"sync"
+	"time"
+)
+
+const (
+	// JobConcurrency is the maximum number of notifications of a single job waiting for execution at once, it keeps fan-out from filling the commands queue
+	JobConcurrency = 1000
+
+	// JobRetention is how long finished jobs are kept for progress queries
+	JobRetention = time.Hour
+)
+
+// Job is a fan-out of a notification to all devices selected by an expression
+type Job struct {
+	ID         string     `json:"id"`
+	Audience   string     `json:"audience"`
+	Total      int        `json:"total"`
+	Sent       int        `json:"sent"`
+	Failed     int        `json:"failed"`
+	Refused    int        `json:"refused"`
+	Created    time.Time  `json:"created"`
+	FinishedAt *time.Time `json:"finished,omitempty"`
+}
+
+// Done returns number of notifications with known outcome
+func (j *Job) Done() int {
+	return j.Sent + j.Failed + j.Refused
+}
+
+// Jobs runs fan-out jobs and keeps their progress
+type Jobs struct {
+	lock sync.RWMutex
+	jobs map[string]*Job
+}
+
+// NewJobs returns an empty jobs tracker
+func NewJobs() *Jobs {
+	jobs := new(Jobs)
+	jobs.jobs = make(map[string]*Job)
+
+	return jobs
+}
+
+// Get returns a snapshot of job progress or nil if job is unknown
+func (jobs *Jobs) Get(id string) *Job {
+	jobs.lock.RLock()
+	defer jobs.lock.RUnlock()
+
+	job, ok := jobs.jobs[id]
+	if !ok {
+		return nil
+	}
+
+	snapshot := *job
+	return &snapshot
+}
+
+// Start sends a copy of the notification to every device token in the background and returns the job tracking its progress
+func (jobs *Jobs) Start(c *apns.Client, expression Expression, tokens []string, notification *apns.Notification) *Job {
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	job := &Job{
+		ID:       hex.EncodeToString(id),
+		Audience: expression.String(),
+		Total:    len(tokens),
+		Created:  time.Now(),
+	}
+
+	jobs.lock.Lock()
+	jobs.evictFinished()
+	jobs.jobs[job.ID] = job
+	snapshot := *job
+	jobs.lock.Unlock()
+
+	go jobs.run(c, job, tokens, notification)
+
+	return &snapshot
+}
+
+func (jobs *Jobs) run(c *apns.Client, job *Job, tokens []string, template *apns.Notification) {
+	pending := make([]apns.CommandInterface, 0, JobConcurrency)
+
+	wait := func(cmd apns.CommandInterface) {
+		var failed bool
+		for commandError := range cmd.Errors() {
+			failed = failed || commandError != nil
+		}
+
+		jobs.lock.Lock()
+		if failed {
+			job.Failed++
+		} else {
+			job.Sent++
+		}
+		jobs.lock.Unlock()
+	}
+
+	for _, token := range tokens {
+		if len(pending) == JobConcurrency {
+			wait(pending[0])
+			pending = pending[1:]
+		}
+
+		// every device gets its own notification identifier
+		notification := apns.NewNotification()
+		identifier := notification.NotificationIdentifier
+		*notification = *template
+		notification.NotificationIdentifier = identifier
+		notification.DeviceToken = token
+
+		cmd := apns.NewPushNotificationCommand(notification)
+		if err := c.ExecuteCommand(cmd); err != nil {
+			jobs.lock.Lock()
+			job.Refused++
+			jobs.lock.Unlock()
+			continue
+		}
+
+		pending = append(pending, cmd)
+	}
+
+	for _, cmd := range pending {
+		wait(cmd)
+	}
+
+	jobs.lock.Lock()
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	jobs.lock.Unlock()
+}
+
+// evictFinished removes jobs finished longer than JobRetention ago, it expects the lock to be held
+func (jobs *Jobs) evictFinished() {
+	for id, job := range jobs.jobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > JobRetention {
+			delete(jobs.jobs, id)
+		}
+	}
+}
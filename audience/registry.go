@@ -0,0 +1,77 @@
+package audience
+
+import (
+	"sync"
+)
+
+// Device is a registered device token with tags used for audience targeting
+type Device struct {
+	DeviceToken string   `json:"deviceToken"`
+	Tags        []string `json:"tags"`
+}
+
+// Registry keeps device tokens and their tags
+type Registry interface {
+	// Register adds device or replaces tags of already registered device
+	Register(device *Device) error
+
+	// Unregister removes device, unknown devices are ignored
+	Unregister(deviceToken string) error
+
+	// Match returns tokens of all devices selected by the expression
+	Match(expression Expression) ([]string, error)
+}
+
+// MemoryRegistry keeps devices in memory
+type MemoryRegistry struct {
+	lock    sync.RWMutex
+	devices map[string]map[string]bool
+}
+
+// NewMemoryRegistry returns an empty in-memory device registry
+func NewMemoryRegistry() *MemoryRegistry {
+	r := new(MemoryRegistry)
+	r.devices = make(map[string]map[string]bool)
+
+	return r
+}
+
+// Register implements Registry interface
+func (r *MemoryRegistry) Register(device *Device) error {
+	tags := make(map[string]bool, len(device.Tags))
+	for _, tag := range device.Tags {
+		tags[tag] = true
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.devices[device.DeviceToken] = tags
+
+	return nil
+}
+
+// Unregister implements Registry interface
+func (r *MemoryRegistry) Unregister(deviceToken string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.devices, deviceToken)
+
+	return nil
+}
+
+// Match implements Registry interface
+func (r *MemoryRegistry) Match(expression Expression) ([]string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	tokens := make([]string, 0)
+	for token, tags := range r.devices {
+		if expression.Matches(tags) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens, nil
+}
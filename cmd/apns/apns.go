@@ -4,41 +4,104 @@
 //
 // Usage
 //
+// Sending SIGINT or SIGTERM to the process stops accepting requests and waits for queued notifications to be sent (see --shutdown-timeout).
+// Sending SIGHUP to the process reads --config file again and reloads certificates from disk, workers reconnect with the reloaded certificate
+// after their next notification. Changes of other options are logged and reported by Stats endpoint as requiring restart.
+//
 // List all available options:
 //  apns --help
 //
+// Print shell completion script (bash, zsh or fish) or man page generated from the options:
+//  apns completion bash
+//  apns man
+//
 // Available options:
 //   --apns-gate-port=2195: Apple's APNS port number
 //   --apns-gate-production="gateway.push.apple.com": FQDN of Apple's APNS production gateway.
 //   --apns-gate-sandbox="gateway.sandbox.push.apple.com": FQDN of Apple's APNS sandbox gateway.
+//   --app=[]: Additional application in form appId:env:certFile:keyFile, can be repeated. Notifications with appId are sent with certificate of that application by its own workers.
+//   --audience-job-endpoint="/audience/jobs": URI of Audience job progress endpoint.
+//   --audience-notification-endpoint="/audience/notification": URI of Audience notification endpoint sending notification to devices selected by tag expression.
+//   --autoscale-cooldown=30s: Time autoscaler waits after scaling before scaling again.
+//   --autoscale-down-occupancy=0: Queue occupancy (0-1) up to which autoscaler removes a quarter of workers.
+//   --autoscale-max-workers=0: Maximum number of workers started by autoscaler. Zero disables autoscaling, --workers is the initial number of workers otherwise.
+//   --autoscale-min-workers=1: Minimum number of workers kept by autoscaler.
+//   --autoscale-up-occupancy=0.01: Queue occupancy (0-1) from which autoscaler doubles workers.
 //   --bind-address=0.0.0.0: IP address the HTTP server should bind to.
 //   --bind-port=9090: Port on which HTTP server is listening.
 //   --cert="": Absolute path to certificate file. Certificate is expected be in PEM format.
 //   --cert-key="": Absolute path to certificate private key file. Certificate key is expected be in PEM format.
+//   --cert-p12="": Absolute path to PKCS#12 (.p12) file with certificate and its private key as exported by Keychain Access. Takes precedence over --cert and --cert-key.
+//   --cert-p12-password="": Password of PKCS#12 file.
+//   --config="": File with one flag per line (e.g. --workers=8), command line flags take precedence. It is read again on SIGHUP.
+//   --connection-probe=false: Keep an additional read-only connection to APNS gateway to detect gateway closing connections early. Workers then reconnect before sending their next notification.
+//   --dev=false: Developer mode. Logs hex dumps and decoded binary protocol frames sent to and received from Apple's gateways.
+//   --dev-show-tokens=false: Don't redact device tokens in developer mode frame dumps.
+//   --devices-endpoint="/devices": URI of Devices endpoint registering device tokens and their tags.
 //   --env="sandbox": Environment of Apple's APNS and Feedback service gateways. For production use specify "production", for testing specify "sandbox".
+//   --errors-stream-endpoint="/errors/stream": URI of Server-Sent Events stream of command errors.
+//   --expired-devices-cache-ttl=1m0s: How long consumers of Expired device tokens endpoint are served from feedback store before Feedback service is checked again. Zero checks Feedback service on every request.
 //   --expired-devices-endpoint="/expired-devices": URI of Expired device tokens endpoint.
+//   --failure-webhook="": URL that receives a POST with notification identifier, correlation ID, device token and APNS status code whenever sending of a notification fails.
 //   --feedback-gate-port=2196: Apple's Feedback service port number
 //   --feedback-gate-production="feedback.push.apple.com": FQDN of Apple's Feedback service production gateway.
 //   --feedback-gate-sandbox="feedback.sandbox.push.apple.com": FQDN of Apple's Feedback service sandbox gateway.
+//   --feedback-poll-interval=0s: Interval of automatic Feedback service checks. Expired devices found are served by Expired device tokens endpoint. Zero disables polling.
+//   --feedback-store="memory": Store of expired devices reported by Feedback service. One of "memory", "bolt" or "redis".
+//   --feedback-store-key-prefix="apns-ms:": Prefix of Redis keys of "redis" feedback store.
+//   --feedback-store-path="apns-ms.db": Path to bolt database file of "bolt" feedback store.
+//   --feedback-store-redis="localhost:6379": Address of Redis server of "redis" feedback store.
+//   --feedback-webhook="": URL that receives a POST with expired devices found by automatic Feedback service checks.
+//   --health-check-interval=30s: Interval of idle worker connection checks. Idle workers whose connection was closed by gateway or was idle longer than --max-idle-time reconnect right away. Zero disables checks.
+//   --health-endpoint="/health": URI of Health endpoint.
+//   --maintenance-check-interval=1m0s: Interval of maintenance status source checks.
+//   --maintenance-endpoint="/maintenance": URI of Maintenance endpoint starting and ending maintenance manually.
+//   --maintenance-status-url="": URL of APNS maintenance status source responding with {"maintenance": bool, "reason": string}. Notifications are buffered in the queue while it reports maintenance. Empty disables checks.
+//   --max-idle-time=10m0s: How long a worker connection may stay idle before the worker connects again. Zero keeps idle connections.
 //   --max-notifications=100000: Number of notification that can be queued for processing at once. Once the queue is full all requests to raw push notification endpoint will result in 503 Service Unavailable response.
 //   --notification-endpoint="/notification": URI of Raw push notification endpoint.
+//   --notification-id-header="X-Notification-Id": Response header of Raw push notification endpoint carrying notification identifier. Empty disables the header.
+//   --notifications-status-endpoint="/notifications/status": URI of Notifications status endpoint.
+//   --resend-window=100: Number of recently written notifications each worker remembers to resend them when APNS drops them after an error response.
+//   --result-store="memory": Store of notification outcomes served by Notifications status endpoint. One of "memory" or "bolt".
+//   --result-store-path="apns-ms.db": Path to bolt database file of "bolt" result store.
+//   --result-store-size=100000: Number of most recent notification outcomes kept by "memory" result store.
+//   --retries=3: Number of times a notification is retried after a transient failure (connection error, APNS processing error or shutdown).
+//   --retry-backoff=100ms: Delay before the first retry. Delay doubles with every following retry.
+//   --retry-max-backoff=10s: Maximum delay between retries.
+//   --shutdown-timeout=30s: Time given to in-flight requests and queued notifications on SIGINT or SIGTERM.
+//   --stall-timeout=30s: How long a worker may execute a single notification before its connection is closed and the notification retried. Zero disables the watchdog.
+//   --stats-endpoint="/stats": URI of Stats endpoint.
+//   --store-compaction-interval=24h0m0s: Interval of bolt database file compaction releasing space of removed entries. Zero disables compaction.
+//   --store-ttl=720h0m0s: How long bolt stores keep notification outcomes and expired devices. Zero keeps them forever.
+//   --templates-dir="": Directory with payload templates, every *.json file is a template named after the file.
+//   --templates-endpoint="/templates/": URI prefix of Template preview endpoint, templates are previewed at {prefix}{name}/preview.
+//   --unknown-aps-keys="reject": Policy for keys of aps dictionary unknown to apns-ms (e.g. mutable-content): reject refuses the notification, warn sends them to APNS and logs a warning, pass sends them silently.
 //   --workers=4: Number of workers that concurently process push notifications. Defaults to 2 * Number of CPU cores.
+//   --workers-endpoint="/workers": URI of Workers endpoint growing or shrinking the pool of workers at runtime.
 //
 //
 package main
 
 import (
-	"fmt"
+	"context"
+	"github.com/andrejbaran/apns-ms"
 	"github.com/andrejbaran/apns-ms/apns"
 	"github.com/andrejbaran/apns-ms/server"
+	"github.com/andrejbaran/apns-ms/stores/boltstore"
+	"github.com/andrejbaran/apns-ms/templates"
 	log "github.com/coreos/pkg/capnslog"
 	"github.com/spf13/pflag"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 var apnsLogger, serverLogger *log.PackageLogger
 
+var templatesDir string
+
 func init() {
 	log.SetFormatter(log.NewPrettyFormatter(os.Stdout, true))
 	apnsLogger = log.NewPackageLogger("apns-microservice", "apns")
@@ -48,26 +111,82 @@ func init() {
 
 	apns.SetLogger(apnsLogger)
 	server.SetLogger(serverLogger)
+	boltstore.SetLogger(apnsLogger)
 }
 
 func main() {
-	apns.SetupCommandLineFlags(pflag.CommandLine)
-	server.SetupCommandLineFlags(pflag.CommandLine)
-	pflag.Parse()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "completion":
+			if len(os.Args) != 3 {
+				apnsLogger.Fatal("Usage: apns completion bash|zsh|fish")
+			}
+
+			if err := writeCompletion(os.Stdout, os.Args[2], flagDefinitions()); err != nil {
+				apnsLogger.Fatal(err)
+			}
+			return
+
+		case "man":
+			writeManPage(os.Stdout, flagDefinitions())
+			return
+		}
+	}
+
+	config, err := parseConfig(os.Args[1:], pflag.ExitOnError)
+	if err != nil {
+		apnsLogger.Fatalf("Configuration couldn't be read: %s", err)
+	}
+
+	if config.Client.DevMode {
+		log.SetGlobalLogLevel(log.DEBUG)
+	}
+
+	store, err := newFeedbackStore()
+	if err != nil {
+		apnsLogger.Fatalf("Feedback store couldn't be created: %s", err)
+	}
+	config.Client.FeedbackStore = store
+
+	config.Client.ResultStore, err = newResultStore()
+	if err != nil {
+		apnsLogger.Fatalf("Result store couldn't be created: %s", err)
+	}
+
+	config.Apps, err = newAppConfigs(config.Client)
+	if err != nil {
+		apnsLogger.Fatalf("Application clients couldn't be created: %s", err)
+	}
+
+	if templatesDir != "" {
+		config.Server.Templates, err = templates.Load(templatesDir)
+		if err != nil {
+			serverLogger.Fatalf("Templates couldn't be loaded: %s", err)
+		}
+	}
 
-	config := apns.NewClientConfig()
-	client, err := apns.NewClient(config)
+	service, err := apnsms.New(config)
 	if err != nil {
 		return
 	}
 
-	http.HandleFunc(server.RawNotificationEndpoint, server.NewRawNotificationHTTPHandlerFunc(client))
-	http.HandleFunc(server.ExpiredDeviceTokensEndpoint, server.NewExpiredDevicesHTTPHandlerFunc(client))
+	reloadOnHangup(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		serverLogger.Infof("Received %s, shutting down", sig)
+		cancel()
+	}()
 
-	serverLogger.Infof("Starting server %s:%d", server.Address.String(), server.Port)
+	serverLogger.Infof("Starting server %s", config.Server.Addr())
 
-	serverErr := http.ListenAndServe(fmt.Sprintf("%s:%d", server.Address.String(), server.Port), nil)
-	if serverErr != nil {
-		serverLogger.Fatalf("Server failed to start: %s", serverErr)
+	err = service.Run(ctx)
+	if err != nil && err != http.ErrServerClosed {
+		serverLogger.Fatalf("Server failed: %s", err)
 	}
+
+	serverLogger.Info("Server stopped")
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/spf13/pflag"
+	"strings"
+)
+
+var apps []string
+
+func setupAppsCommandLineFlags(fs *pflag.FlagSet) {
+	fs.StringSliceVar(&apps, "app", apps, "Additional application in form appId:env:certFile:keyFile, can be repeated. Notifications with appId are sent with certificate of that application by its own workers.")
+}
+
+// newAppConfigs creates client config of every application given by --app flag. Configs inherit options of config
+// except for environment and certificate, result store is shared so notifications status covers all applications.
+func newAppConfigs(config *apns.ClientConfig) (map[string]*apns.ClientConfig, error) {
+	configs := make(map[string]*apns.ClientConfig)
+
+	for _, app := range apps {
+		parts := strings.SplitN(app, ":", 4)
+		if len(parts) != 4 || parts[0] == "" {
+			return nil, errors.New("Invalid application \"" + app + "\", expected appId:env:certFile:keyFile")
+		}
+
+		if _, ok := configs[parts[0]]; ok {
+			return nil, errors.New("Application \"" + parts[0] + "\" is specified more than once")
+		}
+
+		appConfig := *config
+		appConfig.Env = parts[1]
+		appConfig.CertificateFile = parts[2]
+		appConfig.CertificatePrivateKeyFile = parts[3]
+		appConfig.FeedbackStore = apns.NewMemoryFeedbackStore()
+
+		configs[parts[0]] = &appConfig
+	}
+
+	return configs, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andrejbaran/apns-ms"
+	"github.com/spf13/pflag"
+	"io"
+	"strings"
+)
+
+// subcommands are commands of apns binary, without a subcommand it runs the microservice
+var subcommands = []string{"completion", "man"}
+
+// flagDefinitions returns all command line flags with their default values
+func flagDefinitions() []*pflag.Flag {
+	var flags []*pflag.Flag
+
+	newFlagSet(apnsms.NewConfig(), pflag.ContinueOnError).VisitAll(func(flag *pflag.Flag) {
+		flags = append(flags, flag)
+	})
+
+	return flags
+}
+
+// writeCompletion writes completion script of given shell (bash, zsh or fish) for the flags
+func writeCompletion(w io.Writer, shell string, flags []*pflag.Flag) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	default:
+		return errors.New("Unknown shell \"" + shell + "\", expected one of bash, zsh or fish")
+	}
+
+	return nil
+}
+
+func writeBashCompletion(w io.Writer, flags []*pflag.Flag) {
+	var words []string
+	for _, flag := range flags {
+		if flag.Value.Type() == "bool" {
+			words = append(words, "--"+flag.Name)
+		} else {
+			words = append(words, "--"+flag.Name+"=")
+		}
+	}
+
+	fmt.Fprintf(w, "# bash completion for apns, load with: source <(apns completion bash)\n")
+	fmt.Fprintf(w, "_apns() {\n")
+	fmt.Fprintf(w, "    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 2 ] && [ \"${COMP_WORDS[1]}\" = \"completion\" ]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY=( $(compgen -W \"bash zsh fish\" -- \"$cur\") )\n")
+	fmt.Fprintf(w, "        return\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    local words=\"%s\"\n", strings.Join(words, " "))
+	fmt.Fprintf(w, "    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(w, "        words=\"%s $words\"\n", strings.Join(subcommands, " "))
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "    compopt -o nospace\n")
+	fmt.Fprintf(w, "    COMPREPLY=( $(compgen -W \"$words\" -- \"$cur\") )\n")
+	fmt.Fprintf(w, "    if [ ${#COMPREPLY[@]} -eq 1 ] && [ \"${COMPREPLY[0]%%=}\" = \"${COMPREPLY[0]}\" ]; then\n")
+	fmt.Fprintf(w, "        COMPREPLY[0]=\"${COMPREPLY[0]} \"\n")
+	fmt.Fprintf(w, "    fi\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F _apns apns\n")
+}
+
+func writeZshCompletion(w io.Writer, flags []*pflag.Flag) {
+	replacer := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:")
+
+	fmt.Fprintf(w, "#compdef apns\n")
+	fmt.Fprintf(w, "# zsh completion for apns, save as _apns in a directory of $fpath\n")
+	fmt.Fprintf(w, "_arguments \\\n")
+	fmt.Fprintf(w, "  '1::command:((%s))' \\\n", "completion\\:\"print shell completion script\" man\\:\"print man page\"")
+
+	for i, flag := range flags {
+		spec := "--" + flag.Name
+		if flag.Value.Type() != "bool" {
+			spec += "=-"
+		}
+
+		fmt.Fprintf(w, "  '%s[%s]'", spec, replacer.Replace(flag.Usage))
+		if i < len(flags)-1 {
+			fmt.Fprintf(w, " \\")
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func writeFishCompletion(w io.Writer, flags []*pflag.Flag) {
+	replacer := strings.NewReplacer("\\", "\\\\", "'", "\\'")
+
+	fmt.Fprintf(w, "# fish completion for apns, load with: apns completion fish | source\n")
+	fmt.Fprintf(w, "complete -c apns -n '__fish_use_subcommand' -f -a completion -d 'Print shell completion script'\n")
+	fmt.Fprintf(w, "complete -c apns -n '__fish_use_subcommand' -f -a man -d 'Print man page'\n")
+	fmt.Fprintf(w, "complete -c apns -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'\n")
+
+	for _, flag := range flags {
+		if flag.Value.Type() == "bool" {
+			fmt.Fprintf(w, "complete -c apns -l %s -d '%s'\n", flag.Name, replacer.Replace(flag.Usage))
+		} else {
+			fmt.Fprintf(w, "complete -c apns -l %s -r -d '%s'\n", flag.Name, replacer.Replace(flag.Usage))
+		}
+	}
+}
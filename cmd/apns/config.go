@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"github.com/andrejbaran/apns-ms"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+)
+
+var configFile string
+
+// newFlagSet returns a flag set with all command line flags bound to config
+func newFlagSet(config *apnsms.Config, errorHandling pflag.ErrorHandling) *pflag.FlagSet {
+	fs := pflag.NewFlagSet(os.Args[0], errorHandling)
+
+	setupClientCommandLineFlags(fs, config.Client)
+	setupServerCommandLineFlags(fs, config.Server)
+	setupStoreCommandLineFlags(fs)
+	setupAppsCommandLineFlags(fs)
+	fs.StringVar(&templatesDir, "templates-dir", templatesDir, "Directory with payload templates, every *.json file is a template named after the file.")
+	fs.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", config.ShutdownTimeout, "Time given to in-flight requests and queued notifications on SIGINT or SIGTERM.")
+	fs.StringVar(&configFile, "config", configFile, "File with one flag per line (e.g. --workers=8), command line flags take precedence. It is read again on SIGHUP.")
+
+	return fs
+}
+
+// parseConfig returns config with defaults overridden by flags of --config file and command line args
+func parseConfig(args []string, errorHandling pflag.ErrorHandling) (*apnsms.Config, error) {
+	configFile = ""
+	apps = nil
+
+	// command line is parsed first to find the config file
+	err := newFlagSet(apnsms.NewConfig(), errorHandling).Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var fileArgs []string
+	if configFile != "" {
+		fileArgs, err = readConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	apps = nil
+	config := apnsms.NewConfig()
+	err = newFlagSet(config, errorHandling).Parse(append(fileArgs, args...))
+
+	return config, err
+}
+
+// readConfigFile returns flags of config file, blank lines and lines starting with # are skipped
+func readConfigFile(path string) (args []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		args = append(args, line)
+	}
+
+	return args, scanner.Err()
+}
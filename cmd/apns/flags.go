@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/andrejbaran/apns-ms/server"
+	"github.com/spf13/pflag"
+)
+
+// setupClientCommandLineFlags binds apns client options to command line flags using config values as defaults
+func setupClientCommandLineFlags(fs *pflag.FlagSet, config *apns.ClientConfig) {
+	fs.StringVar(&config.Env, "env", config.Env, "Environment of Apple's APNS and Feedback service gateways. For production use specify \"production\", for testing specify \"sandbox\".")
+	fs.Uint64Var(&config.CommandsQueueSize, "max-notifications", config.CommandsQueueSize, "Number of notification that can be queued for processing at once. Once the queue is full all requests to raw push notification endpoint will result in 503 Service Unavailable response.")
+	fs.Uint32Var(&config.NumberOfWorkers, "workers", config.NumberOfWorkers, "Number of workers that concurently process push notifications. Defaults to 2 * Number of CPU cores.")
+	fs.StringVar(&config.CertificateFile, "cert", config.CertificateFile, "Absolute path to certificate file. Certificate is expected be in PEM format.")
+	fs.StringVar(&config.CertificatePrivateKeyFile, "cert-key", config.CertificatePrivateKeyFile, "Absolute path to certificate private key file. Certificate key is expected be in PEM format.")
+	fs.StringVar(&config.CertificateP12File, "cert-p12", config.CertificateP12File, "Absolute path to PKCS#12 (.p12) file with certificate and its private key as exported by Keychain Access. Takes precedence over --cert and --cert-key.")
+	fs.StringVar(&config.CertificateP12Password, "cert-p12-password", config.CertificateP12Password, "Password of PKCS#12 file.")
+	fs.StringVar(&config.FailureWebhookURL, "failure-webhook", config.FailureWebhookURL, "URL that receives a POST with notification identifier, correlation ID, device token and APNS status code whenever sending of a notification fails.")
+	fs.BoolVar(&config.DevMode, "dev", config.DevMode, "Developer mode. Logs hex dumps and decoded binary protocol frames sent to and received from Apple's gateways.")
+	fs.BoolVar(&config.DevShowTokens, "dev-show-tokens", config.DevShowTokens, "Don't redact device tokens in developer mode frame dumps.")
+	fs.Uint32Var(&config.MaxRetries, "retries", config.MaxRetries, "Number of times a notification is retried after a transient failure (connection error, APNS processing error or shutdown).")
+	fs.DurationVar(&config.RetryBackoff, "retry-backoff", config.RetryBackoff, "Delay before the first retry. Delay doubles with every following retry.")
+	fs.DurationVar(&config.RetryMaxBackoff, "retry-max-backoff", config.RetryMaxBackoff, "Maximum delay between retries.")
+	fs.DurationVar(&config.FeedbackPollInterval, "feedback-poll-interval", config.FeedbackPollInterval, "Interval of automatic Feedback service checks. Expired devices found are served by Expired device tokens endpoint. Zero disables polling.")
+	fs.StringVar(&config.FeedbackWebhookURL, "feedback-webhook", config.FeedbackWebhookURL, "URL that receives a POST with expired devices found by automatic Feedback service checks.")
+	fs.BoolVar(&config.ConnectionProbe, "connection-probe", config.ConnectionProbe, "Keep an additional read-only connection to APNS gateway to detect gateway closing connections early. Workers then reconnect before sending their next notification.")
+	fs.DurationVar(&config.StallTimeout, "stall-timeout", config.StallTimeout, "How long a worker may execute a single notification before its connection is closed and the notification retried. Zero disables the watchdog.")
+	fs.DurationVar(&config.HealthCheckInterval, "health-check-interval", config.HealthCheckInterval, "Interval of idle worker connection checks. Idle workers whose connection was closed by gateway or was idle longer than --max-idle-time reconnect right away. Zero disables checks.")
+	fs.DurationVar(&config.MaxIdleTime, "max-idle-time", config.MaxIdleTime, "How long a worker connection may stay idle before the worker connects again. Zero keeps idle connections.")
+	fs.StringVar(&config.MaintenanceStatusURL, "maintenance-status-url", config.MaintenanceStatusURL, "URL of APNS maintenance status source responding with {\"maintenance\": bool, \"reason\": string}. Notifications are buffered in the queue while it reports maintenance. Empty disables checks.")
+	fs.DurationVar(&config.MaintenanceCheckInterval, "maintenance-check-interval", config.MaintenanceCheckInterval, "Interval of maintenance status source checks.")
+	fs.Uint32Var(&config.AutoscaleMinWorkers, "autoscale-min-workers", config.AutoscaleMinWorkers, "Minimum number of workers kept by autoscaler.")
+	fs.Uint32Var(&config.AutoscaleMaxWorkers, "autoscale-max-workers", config.AutoscaleMaxWorkers, "Maximum number of workers started by autoscaler. Zero disables autoscaling, --workers is the initial number of workers otherwise.")
+	fs.DurationVar(&config.AutoscaleCooldown, "autoscale-cooldown", config.AutoscaleCooldown, "Time autoscaler waits after scaling before scaling again.")
+	fs.Float64Var(&config.AutoscaleUpOccupancy, "autoscale-up-occupancy", config.AutoscaleUpOccupancy, "Queue occupancy (0-1) from which autoscaler doubles workers.")
+	fs.Float64Var(&config.AutoscaleDownOccupancy, "autoscale-down-occupancy", config.AutoscaleDownOccupancy, "Queue occupancy (0-1) up to which autoscaler removes a quarter of workers.")
+	fs.StringVar(&config.UnknownApsKeys, "unknown-aps-keys", config.UnknownApsKeys, "Policy for keys of aps dictionary unknown to apns-ms (e.g. mutable-content): reject refuses the notification, warn sends them to APNS and logs a warning, pass sends them silently.")
+	fs.Uint32Var(&config.ResendWindowSize, "resend-window", config.ResendWindowSize, "Number of recently written notifications each worker remembers to resend them when APNS drops them after an error response.")
+
+	fs.StringVar(&config.APNSGatewayProduction, "apns-gate-production", config.APNSGatewayProduction, "FQDN of Apple's APNS production gateway.")
+	fs.StringVar(&config.APNSGatewaySandbox, "apns-gate-sandbox", config.APNSGatewaySandbox, "FQDN of Apple's APNS sandbox gateway.")
+	fs.StringVar(&config.FeedbackGatewayProduction, "feedback-gate-production", config.FeedbackGatewayProduction, "FQDN of Apple's Feedback service production gateway.")
+	fs.StringVar(&config.FeedbackGatewaySandbox, "feedback-gate-sandbox", config.FeedbackGatewaySandbox, "FQDN of Apple's Feedback service sandbox gateway.")
+	fs.Uint16Var(&config.APNSGatewayPort, "apns-gate-port", config.APNSGatewayPort, "Apple's APNS port number")
+	fs.Uint16Var(&config.FeedbackGatewayPort, "feedback-gate-port", config.FeedbackGatewayPort, "Apple's Feedback service port number")
+}
+
+// setupServerCommandLineFlags binds HTTP server options to command line flags using config values as defaults
+func setupServerCommandLineFlags(fs *pflag.FlagSet, config *server.Config) {
+	fs.IPVar(&config.Address, "address", config.Address, "IP address the HTTP server should bind to.")
+	fs.Uint16Var(&config.Port, "port", config.Port, "Port on which HTTP server should listen on.")
+	fs.StringVar(&config.RawNotificationEndpoint, "notification-endpoint", config.RawNotificationEndpoint, "URI of Raw push notification endpoint.")
+	fs.StringVar(&config.NotificationIDHeader, "notification-id-header", config.NotificationIDHeader, "Response header of Raw push notification endpoint carrying notification identifier. Empty disables the header.")
+	fs.StringVar(&config.ExpiredDeviceTokensEndpoint, "expired-devices-endpoint", config.ExpiredDeviceTokensEndpoint, "URI of Expired device tokens endpoint.")
+	fs.DurationVar(&config.ExpiredDevicesCacheTTL, "expired-devices-cache-ttl", config.ExpiredDevicesCacheTTL, "How long consumers of Expired device tokens endpoint are served from feedback store before Feedback service is checked again. Zero checks Feedback service on every request.")
+	fs.StringVar(&config.NotificationsStatusEndpoint, "notifications-status-endpoint", config.NotificationsStatusEndpoint, "URI of Notifications status endpoint.")
+	fs.StringVar(&config.DevicesEndpoint, "devices-endpoint", config.DevicesEndpoint, "URI of Devices endpoint registering device tokens and their tags.")
+	fs.StringVar(&config.AudienceNotificationEndpoint, "audience-notification-endpoint", config.AudienceNotificationEndpoint, "URI of Audience notification endpoint sending notification to devices selected by tag expression.")
+	fs.StringVar(&config.AudienceJobEndpoint, "audience-job-endpoint", config.AudienceJobEndpoint, "URI of Audience job progress endpoint.")
+	fs.StringVar(&config.TemplatesEndpoint, "templates-endpoint", config.TemplatesEndpoint, "URI prefix of Template preview endpoint, templates are previewed at {prefix}{name}/preview.")
+	fs.StringVar(&config.HealthEndpoint, "health-endpoint", config.HealthEndpoint, "URI of Health endpoint.")
+	fs.StringVar(&config.MaintenanceEndpoint, "maintenance-endpoint", config.MaintenanceEndpoint, "URI of Maintenance endpoint starting and ending maintenance manually.")
+	fs.StringVar(&config.WorkersEndpoint, "workers-endpoint", config.WorkersEndpoint, "URI of Workers endpoint growing or shrinking the pool of workers at runtime.")
+	fs.StringVar(&config.StatsEndpoint, "stats-endpoint", config.StatsEndpoint, "URI of Stats endpoint.")
+	fs.StringVar(&config.ErrorsStreamEndpoint, "errors-stream-endpoint", config.ErrorsStreamEndpoint, "URI of Server-Sent Events stream of command errors.")
+}
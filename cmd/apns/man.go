@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/spf13/pflag"
+	"io"
+	"strings"
+)
+
+// roffEscape escapes text for use in a man page
+var roffEscape = strings.NewReplacer("\\", "\\e", "-", "\\-", "'", "\\(aq")
+
+// writeManPage writes apns(1) man page in roff format documenting the flags
+func writeManPage(w io.Writer, flags []*pflag.Flag) {
+	fmt.Fprintf(w, ".TH APNS 1 \"\" \"apns-ms\" \"User Commands\"\n")
+	fmt.Fprintf(w, ".SH NAME\n")
+	fmt.Fprintf(w, "apns \\- Apple Push Notification Service provider exposing HTTP API\n")
+	fmt.Fprintf(w, ".SH SYNOPSIS\n")
+	fmt.Fprintf(w, ".B apns\n[\\fIOPTIONS\\fR]\n.br\n")
+	fmt.Fprintf(w, ".B apns completion\n\\fBbash\\fR|\\fBzsh\\fR|\\fBfish\\fR\n.br\n")
+	fmt.Fprintf(w, ".B apns man\n")
+	fmt.Fprintf(w, ".SH DESCRIPTION\n")
+	fmt.Fprintf(w, "Sends push notifications received by HTTP API to APNS and serves expired device tokens reported by Feedback service.\n")
+	fmt.Fprintf(w, ".PP\n")
+	fmt.Fprintf(w, "SIGINT or SIGTERM stops accepting requests and waits for queued notifications to be sent. ")
+	fmt.Fprintf(w, "SIGHUP reads \\fB\\-\\-config\\fR file again and reloads certificates.\n")
+	fmt.Fprintf(w, ".SH COMMANDS\n")
+	fmt.Fprintf(w, ".TP\n.B completion \\fIshell\\fR\nPrint completion script of bash, zsh or fish.\n")
+	fmt.Fprintf(w, ".TP\n.B man\nPrint this man page.\n")
+	fmt.Fprintf(w, ".SH OPTIONS\n")
+
+	for _, flag := range flags {
+		fmt.Fprintf(w, ".TP\n")
+		if flag.Value.Type() == "bool" {
+			fmt.Fprintf(w, "\\fB\\-\\-%s\\fR\n", roffEscape.Replace(flag.Name))
+		} else {
+			fmt.Fprintf(w, "\\fB\\-\\-%s\\fR=\\fI%s\\fR\n", roffEscape.Replace(flag.Name), roffEscape.Replace(flag.DefValue))
+		}
+		fmt.Fprintf(w, "%s\n", roffEscape.Replace(flag.Usage))
+	}
+}
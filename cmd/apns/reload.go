@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/andrejbaran/apns-ms"
+	"github.com/spf13/pflag"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// reloadOnHangup reads configuration again whenever the process receives SIGHUP and applies it to the service.
+// Certificates are reloaded, other changes are reported as requiring restart.
+func reloadOnHangup(service *apnsms.Service) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			apnsLogger.Info("Received SIGHUP, reloading configuration")
+
+			config, err := parseConfig(os.Args[1:], pflag.ContinueOnError)
+			if err != nil {
+				apnsLogger.Errorf("Configuration couldn't be read, keeping current one: %s", err)
+				continue
+			}
+
+			config.Apps, err = newAppConfigs(config.Client)
+			if err != nil {
+				apnsLogger.Errorf("Configuration couldn't be read, keeping current one: %s", err)
+				continue
+			}
+
+			report, _ := service.Reload(config)
+			apnsLogger.Infof("Configuration reloaded with %d change(s)", len(report.Changes))
+		}
+	}()
+}
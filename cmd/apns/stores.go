@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/andrejbaran/apns-ms/stores/boltstore"
+	"github.com/andrejbaran/apns-ms/stores/redisstore"
+	"github.com/spf13/pflag"
+	"time"
+)
+
+var (
+	feedbackStore           = "memory"
+	feedbackStorePath       = "apns-ms.db"
+	feedbackStoreRedis      = "localhost:6379"
+	feedbackStoreKeyPrefix  = redisstore.DefaultKeyPrefix
+	resultStore             = "memory"
+	resultStorePath         = "apns-ms.db"
+	resultStoreSize         = apns.DefaultResultStoreSize
+	storeTTL                = time.Hour * 24 * 30
+	storeCompactionInterval = time.Hour * 24
+)
+
+// boltStores are opened bolt stores keyed by path, feedback and result stores share the store when their paths are the same
+var boltStores = make(map[string]*boltstore.Store)
+
+func setupStoreCommandLineFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&feedbackStore, "feedback-store", feedbackStore, "Store of expired devices reported by Feedback service. One of \"memory\", \"bolt\" or \"redis\".")
+	fs.StringVar(&feedbackStorePath, "feedback-store-path", feedbackStorePath, "Path to bolt database file of \"bolt\" feedback store.")
+	fs.StringVar(&feedbackStoreRedis, "feedback-store-redis", feedbackStoreRedis, "Address of Redis server of \"redis\" feedback store.")
+	fs.StringVar(&feedbackStoreKeyPrefix, "feedback-store-key-prefix", feedbackStoreKeyPrefix, "Prefix of Redis keys of \"redis\" feedback store.")
+	fs.StringVar(&resultStore, "result-store", resultStore, "Store of notification outcomes served by Notifications status endpoint. One of \"memory\" or \"bolt\".")
+	fs.StringVar(&resultStorePath, "result-store-path", resultStorePath, "Path to bolt database file of \"bolt\" result store.")
+	fs.IntVar(&resultStoreSize, "result-store-size", resultStoreSize, "Number of most recent notification outcomes kept by \"memory\" result store.")
+	fs.DurationVar(&storeTTL, "store-ttl", storeTTL, "How long bolt stores keep notification outcomes and expired devices. Zero keeps them forever.")
+	fs.DurationVar(&storeCompactionInterval, "store-compaction-interval", storeCompactionInterval, "Interval of bolt database file compaction releasing space of removed entries. Zero disables compaction.")
+}
+
+// newFeedbackStore creates feedback store selected by command line flags
+func newFeedbackStore() (apns.FeedbackStore, error) {
+	switch feedbackStore {
+	case "memory":
+		return apns.NewMemoryFeedbackStore(), nil
+	case "bolt":
+		return openBoltStore(feedbackStorePath)
+	case "redis":
+		return redisstore.New(redisstore.NewPool(feedbackStoreRedis), feedbackStoreKeyPrefix), nil
+	}
+
+	return nil, errors.New("Unknown feedback store \"" + feedbackStore + "\"")
+}
+
+// newResultStore creates result store selected by command line flags
+func newResultStore() (apns.ResultStore, error) {
+	switch resultStore {
+	case "memory":
+		return apns.NewMemoryResultStore(resultStoreSize), nil
+	case "bolt":
+		return openBoltStore(resultStorePath)
+	}
+
+	return nil, errors.New("Unknown result store \"" + resultStore + "\"")
+}
+
+// openBoltStore opens bolt store at path once and starts its housekeeping
+func openBoltStore(path string) (*boltstore.Store, error) {
+	if store, ok := boltStores[path]; ok {
+		return store, nil
+	}
+
+	store, err := boltstore.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	boltStores[path] = store
+	go store.Housekeep(storeTTL, storeCompactionInterval)
+
+	return store, nil
+}
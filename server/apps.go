@@ -0,0 +1,20 @@
+package server
+
+import (
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+)
+
+// selectClient returns the client of the application identified by appID, empty appID selects default client c
+func selectClient(c *apns.Client, apps map[string]*apns.Client, appID string) (*apns.Client, error) {
+	if appID == "" {
+		return c, nil
+	}
+
+	client, ok := apps[appID]
+	if !ok {
+		return nil, errors.New("Unknown application \"" + appID + "\"")
+	}
+
+	return client, nil
+}
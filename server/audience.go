@@ -0,0 +1,231 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/andrejbaran/apns-ms/audience"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	devicesCounter              uint64
+	audienceNotificationCounter uint64
+	audienceJobCounter          uint64
+)
+
+// AudienceNotificationRequest is the request data of Audience notification endpoint
+type AudienceNotificationRequest struct {
+	Audience     string             `json:"audience"`
+	Notification *apns.Notification `json:"notification"`
+}
+
+// NewDevicesHTTPHandlerFunc returns a net/http compatible request handler function that registers (POST) and unregisters (DELETE) devices in device registry
+func NewDevicesHTTPHandlerFunc(registry audience.Registry) (f http.HandlerFunc) {
+	f = func(registry audience.Registry) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&devicesCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received devices request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			switch req.Method {
+			case "POST":
+				device := new(audience.Device)
+				err := json.NewDecoder(req.Body).Decode(device)
+				if err == io.EOF {
+					err = errors.New("Device data is missing")
+				}
+				if err == nil {
+					err = validateDeviceToken(device.DeviceToken)
+				}
+
+				if err != nil {
+					responseData = errorResponseData(err)
+					defer finishResponse("Devices", counter, w, http.StatusBadRequest, responseData, startTime)
+					return
+				}
+
+				err = registry.Register(device)
+				if err != nil {
+					logger.Errorf("Couldn't register device: %s", err)
+					defer finishResponse("Devices", counter, w, http.StatusInternalServerError, responseData, startTime)
+					return
+				}
+
+				responseData, _ = json.Marshal(device)
+
+				finishResponse("Devices", counter, w, http.StatusOK, responseData, startTime)
+
+			case "DELETE":
+				deviceToken := req.URL.Query().Get("deviceToken")
+				if err := validateDeviceToken(deviceToken); err != nil {
+					responseData = errorResponseData(err)
+					defer finishResponse("Devices", counter, w, http.StatusBadRequest, responseData, startTime)
+					return
+				}
+
+				if err := registry.Unregister(deviceToken); err != nil {
+					logger.Errorf("Couldn't unregister device: %s", err)
+					defer finishResponse("Devices", counter, w, http.StatusInternalServerError, responseData, startTime)
+					return
+				}
+
+				finishResponse("Devices", counter, w, http.StatusNoContent, responseData, startTime)
+
+			default:
+				finishResponse("Devices", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+			}
+		}
+
+		return handlerFunc
+	}(registry)
+
+	return
+}
+
+// NewAudienceNotificationHTTPHandlerFunc returns a net/http compatible request handler function that sends notification to all registered devices selected by tag expression.
+// Notifications with appId are sent by the client of that application from apps, others by c.
+func NewAudienceNotificationHTTPHandlerFunc(c *apns.Client, apps map[string]*apns.Client, registry audience.Registry, jobs *audience.Jobs) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&audienceNotificationCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received audience notification request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			// check method
+			if req.Method != "POST" {
+				defer finishResponse("Audience notification", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			audienceRequest := &AudienceNotificationRequest{Notification: apns.NewNotification()}
+			err := json.NewDecoder(req.Body).Decode(audienceRequest)
+			if err == io.EOF {
+				err = errors.New("Notification data is missing")
+			}
+
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Audience notification", counter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			client, err := selectClient(c, apps, audienceRequest.Notification.AppID)
+			if err == nil {
+				err = client.CheckApsKeys(audienceRequest.Notification)
+			}
+
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Audience notification", counter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			expression, err := audience.ParseExpression(audienceRequest.Audience)
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Audience notification", counter, w, http.StatusBadRequest, responseData, startTime)
+				return
+			}
+
+			tokens, err := registry.Match(expression)
+			if err != nil {
+				logger.Errorf("Couldn't match devices: %s", err)
+				defer finishResponse("Audience notification", counter, w, http.StatusInternalServerError, responseData, startTime)
+				return
+			}
+
+			job := jobs.Start(client, expression, tokens, audienceRequest.Notification)
+			logger.Infof("Started job %s sending notification to %d device(s) of audience %s", job.ID, job.Total, job.Audience)
+
+			responseData, _ = json.Marshal(job)
+
+			finishResponse("Audience notification", counter, w, http.StatusAccepted, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
+
+// NewAudienceJobHTTPHandlerFunc returns a net/http compatible request handler function that reports progress of audience notification job identified by id query parameter
+func NewAudienceJobHTTPHandlerFunc(jobs *audience.Jobs) (f http.HandlerFunc) {
+	f = func(jobs *audience.Jobs) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&audienceJobCounter, 1)
+
+			var responseData []byte
+
+			logger.Debugf("Received audience job request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			// check method
+			if req.Method != "GET" {
+				defer finishResponse("Audience job", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			job := jobs.Get(req.URL.Query().Get("id"))
+			if job == nil {
+				defer finishResponse("Audience job", counter, w, http.StatusNotFound, responseData, startTime)
+				return
+			}
+
+			responseData, _ = json.Marshal(job)
+
+			finishResponse("Audience job", counter, w, http.StatusOK, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(jobs)
+
+	return
+}
+
+func validateDeviceToken(deviceToken string) error {
+	token, err := hex.DecodeString(deviceToken)
+	if err != nil || len(token) != apns.DeviceTokenItemLength {
+		return errors.New("Device token should be hex encoded 32 bytes long binary string")
+	}
+
+	return nil
+}
+
+func errorResponseData(err error) []byte {
+	responseData, _ := json.Marshal(&struct {
+		Error string `json:"error"`
+	}{
+		Error: err.Error(),
+	})
+
+	return responseData
+}
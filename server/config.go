@@ -0,0 +1,131 @@
+package server
+
+import (
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/andrejbaran/apns-ms/audience"
+	"github.com/andrejbaran/apns-ms/templates"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Config holds configuration of HTTP server and its endpoints
+type Config struct {
+	// Address is IP address the HTTP server should bind to
+	Address net.IP
+
+	// Port is port on which HTTP server is listening
+	Port uint16
+
+	// RawNotificationEndpoint is URI of Raw push notification endpoint
+	RawNotificationEndpoint string
+
+	// ExpiredDeviceTokensEndpoint is URI of Expired device tokens endpoint
+	ExpiredDeviceTokensEndpoint string
+
+	// NotificationsStatusEndpoint is URI of Notifications status endpoint
+	NotificationsStatusEndpoint string
+
+	// DevicesEndpoint is URI of Devices endpoint
+	DevicesEndpoint string
+
+	// AudienceNotificationEndpoint is URI of Audience notification endpoint
+	AudienceNotificationEndpoint string
+
+	// AudienceJobEndpoint is URI of Audience job progress endpoint
+	AudienceJobEndpoint string
+
+	// ErrorsStreamEndpoint is URI of Server-Sent Events stream of command errors
+	ErrorsStreamEndpoint string
+
+	// TemplatesEndpoint is URI prefix of Template preview endpoint ({prefix}{name}/preview)
+	TemplatesEndpoint string
+
+	// StatsEndpoint is URI of Stats endpoint
+	StatsEndpoint string
+
+	// HealthEndpoint is URI of Health endpoint
+	HealthEndpoint string
+
+	// MaintenanceEndpoint is URI of Maintenance endpoint
+	MaintenanceEndpoint string
+
+	// WorkersEndpoint is URI of Workers endpoint
+	WorkersEndpoint string
+
+	// NotificationIDHeader is the response header of Raw push notification endpoint carrying notification identifier. Empty disables the header.
+	NotificationIDHeader string
+
+	// Apps are clients of additional applications keyed by app identifier. Notifications with appId are sent by the client of that application,
+	// notifications without appId by the default client. Other endpoints serve the default client.
+	Apps map[string]*apns.Client
+
+	// Registry keeps devices targeted by Audience notification endpoint. Defaults to MemoryRegistry
+	Registry audience.Registry
+
+	// Templates are payload templates. Defaults to an empty set
+	Templates *templates.Set
+
+	// ExpiredDevicesCacheTTL is how long consumers of Expired device tokens endpoint are served from feedback store before Feedback service is checked again
+	ExpiredDevicesCacheTTL time.Duration
+}
+
+// NewConfig returns new server config with default values
+func NewConfig() (config *Config) {
+	config = new(Config)
+	config.Address = net.ParseIP("0.0.0.0")
+	config.Port = 9090
+	config.RawNotificationEndpoint = "/notification"
+	config.ExpiredDeviceTokensEndpoint = "/expired-devices"
+	config.NotificationsStatusEndpoint = "/notifications/status"
+	config.DevicesEndpoint = "/devices"
+	config.AudienceNotificationEndpoint = "/audience/notification"
+	config.AudienceJobEndpoint = "/audience/jobs"
+	config.ErrorsStreamEndpoint = "/errors/stream"
+	config.TemplatesEndpoint = "/templates/"
+	config.StatsEndpoint = "/stats"
+	config.HealthEndpoint = "/health"
+	config.MaintenanceEndpoint = "/maintenance"
+	config.WorkersEndpoint = "/workers"
+	config.NotificationIDHeader = "X-Notification-Id"
+	config.ExpiredDevicesCacheTTL = time.Minute
+	config.Registry = audience.NewMemoryRegistry()
+	config.Templates = templates.NewSet()
+
+	return
+}
+
+// Addr returns address the HTTP server should listen on in host:port form
+func (config *Config) Addr() string {
+	return net.JoinHostPort(config.Address.String(), strconv.Itoa(int(config.Port)))
+}
+
+// NewServeMux returns a request multiplexer with all API endpoints registered at URIs from config
+func NewServeMux(c *apns.Client, config *Config) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	if config.Registry == nil {
+		config.Registry = audience.NewMemoryRegistry()
+	}
+	jobs := audience.NewJobs()
+
+	if config.Templates == nil {
+		config.Templates = templates.NewSet()
+	}
+
+	mux.HandleFunc(config.RawNotificationEndpoint, NewRawNotificationHTTPHandlerFunc(c, config.Apps, config.NotificationIDHeader))
+	mux.HandleFunc(config.ExpiredDeviceTokensEndpoint, NewExpiredDevicesHTTPHandlerFunc(c, config.ExpiredDevicesCacheTTL))
+	mux.HandleFunc(config.NotificationsStatusEndpoint, NewNotificationsStatusHTTPHandlerFunc(c))
+	mux.HandleFunc(config.DevicesEndpoint, NewDevicesHTTPHandlerFunc(config.Registry))
+	mux.HandleFunc(config.AudienceNotificationEndpoint, NewAudienceNotificationHTTPHandlerFunc(c, config.Apps, config.Registry, jobs))
+	mux.HandleFunc(config.AudienceJobEndpoint, NewAudienceJobHTTPHandlerFunc(jobs))
+	mux.HandleFunc(config.ErrorsStreamEndpoint, NewErrorsStreamHTTPHandlerFunc(c))
+	mux.HandleFunc(config.TemplatesEndpoint, NewTemplatePreviewHTTPHandlerFunc(config.Templates, config.TemplatesEndpoint))
+	mux.HandleFunc(config.StatsEndpoint, NewStatsHTTPHandlerFunc(c))
+	mux.HandleFunc(config.HealthEndpoint, NewHealthHTTPHandlerFunc(c, config.Apps))
+	mux.HandleFunc(config.MaintenanceEndpoint, NewMaintenanceHTTPHandlerFunc(c, config.Apps))
+	mux.HandleFunc(config.WorkersEndpoint, NewWorkersHTTPHandlerFunc(c, config.Apps))
+
+	return mux
+}
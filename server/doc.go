@@ -2,17 +2,33 @@
 //
 // HTTP API
 //
-// API has 2 endpoints:
+// API has 12 endpoints:
 //
 // * for sending raw push notifications (APN service).
 //
+// * for registering device tokens with tags, sending notifications to devices selected by tag expression and checking progress of such sending.
+//
 // * for fetching expired device tokens (Feedback service).
 //
-// Note: sending push notification from template will be available soon.
+// * for querying outcomes of sent notifications.
+//
+// * for streaming command errors (Server-Sent Events).
+//
+// * for checking worker, queue and runtime statistics.
+//
+// * for health checks.
+//
+// * for previewing payload templates.
+//
+// * for starting and ending APNS maintenance.
+//
+// * for scaling the pool of workers at runtime.
+//
+// Note: sending push notification from template will be available soon, templates can be previewed already.
 //
 // Raw push notification endpoint
 //
-// You can set URI for this endpoint by providing command line argument
+// You can set URI for this endpoint with Config.RawNotificationEndpoint or by providing apns binary command line argument
 //  --notification-endpoint="/my-send-push-notification-endpoint"
 //
 // This endpoint accepts POST requests with JSON formatted notification data. Notification data format resembles Apple's notification format specification and
@@ -30,6 +46,10 @@
 //       "minLength":64,
 //       "maxLength":64
 //     },
+//     "topic":{
+//       "id":"topic",
+//       "type":"string"
+//     },
 //     "payload":{
 //       "id":"payload",
 //       "type":"object",
@@ -132,6 +152,14 @@
 //       "id":"identifier",
 //       "type":"string"
 //     },
+//     "correlationId":{
+//       "id":"correlationId",
+//       "type":"string"
+//     },
+//     "appId":{
+//       "id":"appId",
+//       "type":"string"
+//     },
 //     "priority":{
 //       "id":"priority",
 //       "type":"integer",
@@ -144,6 +172,12 @@
 //   ]
 //  }
 //
+// Notification identifier is returned in X-Notification-Id response header (Config.NotificationIDHeader, empty value disables it).
+// Optional correlationId is an opaque client-supplied value which isn't sent to APNS but is included in the response, failure webhooks and errors stream.
+// It can also be provided in X-Correlation-Id request header, correlation ID is then echoed in X-Correlation-Id response header.
+//
+// Optional appId selects the application (client from Config.Apps) the notification is sent by, notifications without appId are sent by the default client.
+//
 // Possible responses:
 //
 // 	202 Accepted
@@ -180,6 +214,7 @@
 //  Content-Type: application/json; charset=utf8
 //  Content-Length: 199
 //  Date: Wed, 21 Oct 2015 08:18:16 GMT
+//  X-Notification-Id: 0507e79b
 //
 //  {
 //   "deviceToken": "b8e0c9ce2114fc73adf117de0c97376626ef9c34bbfec4fe18e1fe0b96321cae",
@@ -193,18 +228,39 @@
 //   "identifier": "0507e79b"
 //  }
 //
+// Audience targeting endpoints
+//
+// You can set URIs of these endpoints with Config.DevicesEndpoint, Config.AudienceNotificationEndpoint and Config.AudienceJobEndpoint or by providing apns binary command line arguments
+//  --devices-endpoint="/my-devices-endpoint"
+//  --audience-notification-endpoint="/my-audience-endpoint"
+//  --audience-job-endpoint="/my-jobs-endpoint"
+//
+// Devices endpoint registers (POST {"deviceToken": "...", "tags": ["country:DE", "app:foo"]}) and unregisters (DELETE with deviceToken query parameter) devices in Config.Registry.
+//
+// Audience notification endpoint accepts POST requests with tag expression combining tags with AND, OR, NOT and parentheses and notification data:
+//  {"audience": "country:DE AND app:foo", "notification": {"payload": {"aps": {"alert": "Hi there!"}}}}
+// Notification is sent to every matching device in background, 202 Accepted response includes job id. Progress of the job
+// (total, sent, failed and refused notifications) is returned by Audience job endpoint for GET requests with id query parameter.
+//
 // Expired device tokens endpoint
 //
-// You can set URI for this endpoint by providing command line argument
+// You can set URI for this endpoint with Config.ExpiredDeviceTokensEndpoint or by providing apns binary command line argument
 //  --expired-devices-endpoint="/my-feedback-endpoint"
 //
-// This endpoint accepts GET requests. Response includes a json encoded list of expired device tokens if there where any new since the last check.
+// This endpoint accepts GET requests. Response includes a json encoded list of expired device tokens kept in feedback store and next cursor.
+// Optional query parameters since (RFC 3339 time or unix timestamp), cursor (next value of previous response) and limit (1000 at most)
+// allow incremental retrieval of expired devices.
 //
 // Possible responses:
 //
 // 	200 OK
 // Means that request to Feedback service was successfull. Response includes a json encoded list of expired device tokens with timestamp of the expiry.
 // Per Apple's recommendation you should always check whether the device hasn't reregister after the timestamp of expiry. In that case the expiry should be ignored.
+// 	304 Not Modified
+// Means that the result set identified by If-None-Match or If-Modified-Since request header hasn't changed.
+// Feedback service is checked at most once per --expired-devices-cache-ttl, consumers polling within that interval are served from feedback store.
+// 	400 Bad Request
+// Means that since, cursor or limit parameter is invalid. Response include json encoded error message.
 // 	405 Method Not Allowed
 // Means that request type was not "GET". Response Content-Length is zero.
 // 	500 Internal Server Error
@@ -232,4 +288,87 @@
 //   ]
 //  }
 //
+// Notifications status endpoint
+//
+// You can set URI for this endpoint with Config.NotificationsStatusEndpoint or by providing apns binary command line argument
+//  --notifications-status-endpoint="/my-status-endpoint"
+//
+// This endpoint accepts POST requests with json encoded list of up to 1000 notification identifiers and responds with their current outcomes
+// (queued, sent or failed) kept in result store. Identifiers without a known outcome are listed in unknown.
+//
+// Request:
+//  POST /my-status-endpoint HTTP/1.1
+//  Content-Type: application/json
+//
+//  {"identifiers": ["0507e79b", "1a2b3c4d"]}
+//
+// Response:
+//  HTTP/1.1 200 OK
+//  Content-Type: application/json; charset=utf8
+//
+//  {
+//   "results": [
+//     {
+//       "identifier": "0507e79b",
+//       "status": "failed",
+//       "apnsStatus": 8,
+//       "error": "apns: Invalid token for notification #0507e79b",
+//       "updated": "2015-10-21T08:18:16.632Z"
+//     }
+//   ],
+//   "unknown": ["1a2b3c4d"]
+//  }
+//
+// Errors stream endpoint
+//
+// You can set URI for this endpoint with Config.ErrorsStreamEndpoint or by providing apns binary command line argument
+//  --errors-stream-endpoint="/my-errors-endpoint"
+//
+// This endpoint accepts GET requests and keeps the connection open, streaming every command error as a Server-Sent Event.
+// Event data is a json object with notification identifier, correlation ID, device token, APNS status code and error message.
+//
+//  id: 1
+//  event: error
+//  data: {"identifier":"0507e79b","deviceToken":"b8e0c9ce...","status":8,"error":"apns: Invalid token for notification #0507e79b"}
+//
+// Stats endpoint
+//
+// You can set URI for this endpoint with Config.StatsEndpoint or by providing apns binary command line argument
+//  --stats-endpoint="/my-stats-endpoint"
+//
+// This endpoint accepts GET requests and responds with json encoded number of running workers, queue occupancy and go runtime statistics.
+// Warnings included in the response hint at tuning of --workers and --max-notifications.
+//
+// Template preview endpoint
+//
+// You can set URI prefix for this endpoint with Config.TemplatesEndpoint or by providing apns binary command line argument
+//  --templates-endpoint="/my-templates-endpoint/"
+//
+// This endpoint accepts POST requests to {prefix}{name}/preview with variables ({"variables": {...}}), renders template of Config.Templates
+// without sending it and responds with resulting payload, its size in bytes and validation warnings.
+//
+// Health endpoint
+//
+// You can set URI for this endpoint with Config.HealthEndpoint or by providing apns binary command line argument
+//  --health-endpoint="/my-health-endpoint"
+//
+// This endpoint accepts GET requests and responds with 200 OK when the default client and clients of all applications have running workers,
+// otherwise with 503 Service Unavailable.
+//
+// Maintenance endpoint
+//
+// You can set URI for this endpoint with Config.MaintenanceEndpoint or by providing apns binary command line argument
+//  --maintenance-endpoint="/my-maintenance-endpoint"
+//
+// This endpoint accepts POST requests ({"active": true, "reason": "...", "appId": ""}) manually starting or ending maintenance of a client
+// and GET requests (appId query parameter). Both respond with current maintenance state. Notifications are buffered in the queue during maintenance.
+//
+// Workers endpoint
+//
+// You can set URI for this endpoint with Config.WorkersEndpoint or by providing apns binary command line argument
+//  --workers-endpoint="/my-workers-endpoint"
+//
+// This endpoint accepts POST requests ({"count": 16, "appId": ""}) growing or shrinking the pool of workers of a client at runtime
+// and GET requests (appId query parameter). Both respond with the number of running and configured workers.
+//
 package server
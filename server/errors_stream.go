@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrorsStreamKeepAliveInterval is the interval of keep-alive comments sent to idle errors stream subscribers
+const ErrorsStreamKeepAliveInterval = time.Second * 15
+
+var errorsStreamCounter uint64
+
+// NewErrorsStreamHTTPHandlerFunc returns a net/http compatible request handler function that streams command errors as Server-Sent Events
+func NewErrorsStreamHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&errorsStreamCounter, 1)
+
+			logger.Infof("Received errors stream request #%d", counter)
+
+			// check method
+			if req.Method != "GET" {
+				defer finishResponse("Errors stream", counter, w, http.StatusMethodNotAllowed, nil, startTime)
+				return
+			}
+
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				logger.Errorf("Response writer doesn't support flushing, errors can't be streamed")
+				defer finishResponse("Errors stream", counter, w, http.StatusInternalServerError, nil, startTime)
+				return
+			}
+
+			errors, unsubscribe := c.SubscribeErrors()
+			defer unsubscribe()
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "text/event-stream; charset=utf8")
+			responseHeaders.Set("Cache-Control", "no-cache")
+			responseHeaders.Set("Connection", "keep-alive")
+
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+
+			keepAlive := time.NewTicker(ErrorsStreamKeepAliveInterval)
+			defer keepAlive.Stop()
+
+			var eventID uint64
+			for {
+				select {
+				case commandError, open := <-errors:
+					if !open {
+						return
+					}
+
+					eventData, _ := json.Marshal(apns.NewFailedNotification(commandError))
+					eventID++
+
+					fmt.Fprintf(w, "id: %d\nevent: error\ndata: %s\n\n", eventID, eventData)
+					flusher.Flush()
+
+				case <-keepAlive.C:
+					fmt.Fprint(w, ": keep-alive\n\n")
+					flusher.Flush()
+
+				case <-req.Context().Done():
+					logger.Infof("Errors stream request #%d closed by client after %s", counter, time.Now().Sub(startTime))
+					return
+				}
+			}
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
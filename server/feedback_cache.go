@@ -0,0 +1,90 @@
+package server
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// feedbackCache limits Feedback service checks triggered by Expired device tokens endpoint to one per cache TTL
+// so that consumers polling within that interval are served from feedback store
+type feedbackCache struct {
+	lock sync.Mutex
+
+	ttl     time.Duration
+	checked time.Time
+}
+
+func newFeedbackCache(ttl time.Duration) *feedbackCache {
+	cache := new(feedbackCache)
+	cache.ttl = ttl
+
+	return cache
+}
+
+// refresh checks Feedback service if the last check is older than cache TTL. When the client polls Feedback service by itself nothing is checked.
+func (fc *feedbackCache) refresh(c *apns.Client) (err error) {
+	if c.Config.FeedbackPollInterval > 0 {
+		return
+	}
+
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+
+	if !fc.checked.IsZero() && time.Now().Sub(fc.checked) < fc.ttl {
+		logger.Debugf("Feedback service was checked at %s, serving feedback store", fc.checked)
+		return
+	}
+
+	_, err = c.CheckFeedbackService()
+	if err != nil {
+		return
+	}
+
+	fc.checked = time.Now()
+
+	return
+}
+
+// etag returns strong entity tag of response body
+func etag(body []byte) string {
+	checksum := sha1.Sum(body)
+	return "\"" + hex.EncodeToString(checksum[:]) + "\""
+}
+
+// notModified checks request's conditional headers against response entity tag and modification time
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		return match == etag || match == "*"
+	}
+
+	if since := req.Header.Get("If-Modified-Since"); since != "" {
+		sinceTime, err := http.ParseTime(since)
+		return err == nil && !lastModified.Truncate(time.Second).After(sinceTime)
+	}
+
+	return false
+}
+
+// parseSince parses since query parameter given either as RFC 3339 time or unix timestamp
+func parseSince(value string) (since time.Time, err error) {
+	if value == "" {
+		return
+	}
+
+	if timestamp, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+		return time.Unix(timestamp, 0), nil
+	}
+
+	since, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		err = errors.New("Parameter since should be RFC 3339 time or unix timestamp")
+	}
+
+	return
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var healthCounter uint64
+
+// Health is the response data of Health endpoint
+type Health struct {
+	Status string `json:"status"`
+}
+
+// NewHealthHTTPHandlerFunc returns a net/http compatible request handler function that reports whether the default client and all application clients have running workers
+func NewHealthHTTPHandlerFunc(c *apns.Client, apps map[string]*apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&healthCounter, 1)
+
+			var responseData []byte
+
+			logger.Debugf("Received health request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			// check method
+			if req.Method != "GET" {
+				defer finishResponse("Health", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			status := http.StatusOK
+			health := &Health{Status: "ok"}
+
+			healthy := c.ActiveWorkers() > 0
+			for _, client := range apps {
+				healthy = healthy && client.ActiveWorkers() > 0
+			}
+
+			if !healthy {
+				status = http.StatusServiceUnavailable
+				health.Status = "unavailable"
+			}
+
+			responseData, _ = json.Marshal(health)
+
+			finishResponse("Health", counter, w, status, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var maintenanceCounter uint64
+
+// MaintenanceRequest is the request data of Maintenance endpoint
+type MaintenanceRequest struct {
+	Active bool   `json:"active"`
+	Reason string `json:"reason"`
+	AppID  string `json:"appId"`
+}
+
+// NewMaintenanceHTTPHandlerFunc returns a net/http compatible request handler function that returns maintenance state of a client (GET, appId query parameter)
+// or manually starts or ends maintenance (POST). Notifications are buffered in the queue during maintenance.
+func NewMaintenanceHTTPHandlerFunc(c *apns.Client, apps map[string]*apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&maintenanceCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received maintenance request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			maintenanceRequest := new(MaintenanceRequest)
+
+			switch req.Method {
+			case "GET":
+				maintenanceRequest.AppID = req.URL.Query().Get("appId")
+
+			case "POST":
+				err := json.NewDecoder(req.Body).Decode(maintenanceRequest)
+				if err != nil {
+					responseData = errorResponseData(err)
+					defer finishResponse("Maintenance", counter, w, http.StatusBadRequest, responseData, startTime)
+					return
+				}
+
+			default:
+				defer finishResponse("Maintenance", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			client, err := selectClient(c, apps, maintenanceRequest.AppID)
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Maintenance", counter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			if req.Method == "POST" {
+				client.SetMaintenance(maintenanceRequest.Active, maintenanceRequest.Reason)
+			}
+
+			responseData, _ = json.Marshal(client.Maintenance())
+
+			finishResponse("Maintenance", counter, w, http.StatusOK, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
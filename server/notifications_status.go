@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// NotificationsStatusMaxIdentifiers is the maximum number of notification identifiers accepted by Notifications status endpoint at once
+const NotificationsStatusMaxIdentifiers = 1000
+
+var notificationsStatusCounter uint64
+
+// NotificationsStatusRequest is the request data of Notifications status endpoint
+type NotificationsStatusRequest struct {
+	Identifiers []string `json:"identifiers"`
+}
+
+// NotificationsStatusResponse is the response data of Notifications status endpoint
+type NotificationsStatusResponse struct {
+	Results []*apns.Result `json:"results"`
+	Unknown []string       `json:"unknown"`
+}
+
+// NewNotificationsStatusHTTPHandlerFunc returns a net/http compatible request handler function that reports current outcomes of notifications from result store
+func NewNotificationsStatusHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&notificationsStatusCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received notifications status request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			// check method
+			if req.Method != "POST" {
+				defer finishResponse("Notifications status", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			statusRequest := new(NotificationsStatusRequest)
+			err := json.NewDecoder(req.Body).Decode(statusRequest)
+
+			if err == io.EOF || (err == nil && len(statusRequest.Identifiers) == 0) {
+				err = errors.New("Notification identifiers are missing")
+			} else if err == nil && len(statusRequest.Identifiers) > NotificationsStatusMaxIdentifiers {
+				err = errors.New("At most " + strconv.Itoa(NotificationsStatusMaxIdentifiers) + " notification identifiers can be queried at once")
+			}
+
+			if err != nil {
+				responseData, _ = json.Marshal(&struct {
+					Error string `json:"error"`
+				}{
+					Error: err.Error(),
+				})
+
+				defer finishResponse("Notifications status", counter, w, http.StatusBadRequest, responseData, startTime)
+				return
+			}
+
+			results, err := c.NotificationResults(statusRequest.Identifiers)
+			if err != nil {
+				logger.Errorf("Couldn't read notification results: %s", err)
+
+				defer finishResponse("Notifications status", counter, w, http.StatusInternalServerError, responseData, startTime)
+				return
+			}
+
+			rsp := &NotificationsStatusResponse{
+				Results: make([]*apns.Result, 0, len(results)),
+				Unknown: make([]string, 0),
+			}
+
+			for _, identifier := range statusRequest.Identifiers {
+				if result, ok := results[identifier]; ok {
+					rsp.Results = append(rsp.Results, result)
+				} else {
+					rsp.Unknown = append(rsp.Unknown, identifier)
+				}
+			}
+
+			responseData, _ = json.Marshal(rsp)
+
+			finishResponse("Notifications status", counter, w, http.StatusOK, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
@@ -4,37 +4,30 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/andrejbaran/apns-ms/apns"
-	"github.com/spf13/pflag"
 	"io"
-	"net"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
 
-var (
-	// Address is IP address the HTTP server should bind to
-	Address = net.ParseIP("0.0.0.0")
-	// Port is port on which HTTP server is listening
-	Port uint16 = 9090
-	// RawNotificationEndpoint is URI of Raw push notification endpoint
-	RawNotificationEndpoint = "/notification"
-	// ExpiredDeviceTokensEndpoint is URI of Expired device tokens endpoint
-	ExpiredDeviceTokensEndpoint = "/expired-devices"
+const (
+	// ExpiredDevicesPageSize is the maximum number of expired devices returned by Expired device tokens endpoint at once
+	ExpiredDevicesPageSize = 1000
+
+	// CorrelationIDHeader is the request header carrying client-supplied correlation ID of the notification, it's echoed in the response
+	CorrelationIDHeader = "X-Correlation-Id"
+)
 
+var (
 	notificationCounter uint64
 	feedbackCounter     uint64
 )
 
-func setupHTTPCommandLineFlags(fs *pflag.FlagSet) {
-	fs.IPVar(&Address, "address", Address, "IP address the HTTP server should bind to.")
-	fs.Uint16Var(&Port, "port", Port, "Port on which HTTP server should listen on.")
-	fs.StringVar(&RawNotificationEndpoint, "notification-endpoint", RawNotificationEndpoint, "URI of Raw push notification endpoint.")
-	fs.StringVar(&ExpiredDeviceTokensEndpoint, "expired-devices-endpoint", ExpiredDeviceTokensEndpoint, "URI of Expired device tokens endpoint.")
-}
-
-// NewRawNotificationHTTPHandlerFunc returns a net/http compatible request handler function that expects raw notification data and sends notification to APN service
-func NewRawNotificationHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
+// NewRawNotificationHTTPHandlerFunc returns a net/http compatible request handler function that expects raw notification data and sends notification to APN service.
+// Notifications with appId are sent by the client of that application from apps, others by c.
+// Notification identifier is returned in identifierHeader response header unless it's empty.
+func NewRawNotificationHTTPHandlerFunc(c *apns.Client, apps map[string]*apns.Client, identifierHeader string) (f http.HandlerFunc) {
 	f = func(c *apns.Client) http.HandlerFunc {
 		var handlerFunc http.HandlerFunc
 
@@ -79,8 +72,44 @@ func NewRawNotificationHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
 				return
 			}
 
+			client, appError := selectClient(c, apps, notification.AppID)
+			if appError != nil {
+				responseData, _ = json.Marshal(&struct {
+					Error string `json:"error"`
+				}{
+					Error: appError.Error(),
+				})
+
+				defer finishResponse("Send push notification", notificationCounter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			if keysError := client.CheckApsKeys(notification); keysError != nil {
+				responseData, _ = json.Marshal(&struct {
+					Error string `json:"error"`
+				}{
+					Error: keysError.Error(),
+				})
+
+				defer finishResponse("Send push notification", notificationCounter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			// correlation ID in notification data takes precedence over the header
+			if notification.CorrelationID == "" {
+				notification.CorrelationID = req.Header.Get(CorrelationIDHeader)
+			}
+
+			if notification.CorrelationID != "" {
+				responseHeaders.Set(CorrelationIDHeader, notification.CorrelationID)
+			}
+
+			if identifierHeader != "" {
+				responseHeaders.Set(identifierHeader, notification.NotificationIdentifier)
+			}
+
 			cmd := apns.NewPushNotificationCommand(notification)
-			err := c.ExecuteCommand(cmd)
+			err := client.ExecuteCommand(cmd)
 
 			commandError := <-cmd.Errors()
 
@@ -121,11 +150,14 @@ func NewRawNotificationHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
 	return
 }
 
-// NewExpiredDevicesHTTPHandlerFunc returns a net/http compatible request handler function for fetching Feedback service data
-func NewExpiredDevicesHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
+// NewExpiredDevicesHTTPHandlerFunc returns a net/http compatible request handler function for fetching Feedback service data.
+// Feedback service is checked at most once per cacheTTL, requests in between are served from feedback store.
+func NewExpiredDevicesHTTPHandlerFunc(c *apns.Client, cacheTTL time.Duration) (f http.HandlerFunc) {
 	f = func(c *apns.Client) http.HandlerFunc {
 		var handlerFunc http.HandlerFunc
 
+		cache := newFeedbackCache(cacheTTL)
+
 		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
 			startTime := time.Now()
 
@@ -144,7 +176,17 @@ func NewExpiredDevicesHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
 				return
 			}
 
-			response, err := c.CheckFeedbackService()
+			query := req.URL.Query()
+
+			since, err := parseSince(query.Get("since"))
+
+			limit := ExpiredDevicesPageSize
+			if value := query.Get("limit"); err == nil && value != "" {
+				limit, err = strconv.Atoi(value)
+				if err != nil || limit <= 0 || limit > ExpiredDevicesPageSize {
+					err = errors.New("Parameter limit should be a number between 1 and " + strconv.Itoa(ExpiredDevicesPageSize))
+				}
+			}
 
 			if err != nil {
 				responseData, _ = json.Marshal(&struct {
@@ -153,11 +195,47 @@ func NewExpiredDevicesHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
 					Error: err.Error(),
 				})
 
-				defer finishResponse("Check feedback service", feedbackCounter, w, http.StatusInternalServerError, responseData, startTime)
+				defer finishResponse("Check feedback service", feedbackCounter, w, http.StatusBadRequest, responseData, startTime)
+				return
+			}
+
+			var response *apns.FeedbackResponse
+			err = cache.refresh(c)
+			if err == nil {
+				response, err = c.ExpiredDevices(since, query.Get("cursor"), limit)
+			}
+
+			if err != nil {
+				status := http.StatusInternalServerError
+				if err == apns.ErrInvalidCursor {
+					status = http.StatusBadRequest
+				}
+
+				responseData, _ = json.Marshal(&struct {
+					Error string `json:"error"`
+				}{
+					Error: err.Error(),
+				})
+
+				defer finishResponse("Check feedback service", feedbackCounter, w, status, responseData, startTime)
+				return
+			}
+
+			body, _ := json.Marshal(response)
+			bodyETag := etag(body)
+			lastModified := c.FeedbackModified()
+
+			responseHeaders.Set("ETag", bodyETag)
+			if !lastModified.IsZero() {
+				responseHeaders.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+			}
+
+			if notModified(req, bodyETag, lastModified) {
+				defer finishResponse("Check feedback service", feedbackCounter, w, http.StatusNotModified, nil, startTime)
 				return
 			}
 
-			responseData, _ = json.Marshal(response)
+			responseData = body
 
 			finishResponse("Check feedback service", feedbackCounter, w, http.StatusOK, responseData, startTime)
 		}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var statsCounter uint64
+
+// NewStatsHTTPHandlerFunc returns a net/http compatible request handler function that reports client's worker, queue and runtime statistics
+func NewStatsHTTPHandlerFunc(c *apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&statsCounter, 1)
+
+			var responseData []byte
+
+			logger.Debugf("Received stats request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			// check method
+			if req.Method != "GET" {
+				defer finishResponse("Stats", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			responseData, _ = json.Marshal(c.Stats())
+
+			finishResponse("Stats", counter, w, http.StatusOK, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
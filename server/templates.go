@@ -0,0 +1,81 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/templates"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var templatePreviewCounter uint64
+
+// TemplatePreviewRequest is the request data of Template preview endpoint
+type TemplatePreviewRequest struct {
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// NewTemplatePreviewHTTPHandlerFunc returns a net/http compatible request handler function that renders template named by the URI
+// (prefix followed by "{name}/preview") with supplied variables and returns resulting payload, its size and validation warnings
+func NewTemplatePreviewHTTPHandlerFunc(set *templates.Set, prefix string) (f http.HandlerFunc) {
+	f = func(set *templates.Set) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&templatePreviewCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received template preview request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			name := strings.TrimPrefix(req.URL.Path, prefix)
+			if !strings.HasSuffix(name, "/preview") {
+				defer finishResponse("Template preview", counter, w, http.StatusNotFound, responseData, startTime)
+				return
+			}
+			name = strings.TrimSuffix(name, "/preview")
+
+			// check method
+			if req.Method != "POST" {
+				defer finishResponse("Template preview", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			previewRequest := new(TemplatePreviewRequest)
+			err := json.NewDecoder(req.Body).Decode(previewRequest)
+			if err != nil && err != io.EOF {
+				responseData = errorResponseData(err)
+				defer finishResponse("Template preview", counter, w, http.StatusBadRequest, responseData, startTime)
+				return
+			}
+
+			preview, err := set.Preview(name, previewRequest.Variables)
+			if err == templates.ErrUnknownTemplate {
+				responseData = errorResponseData(err)
+				defer finishResponse("Template preview", counter, w, http.StatusNotFound, responseData, startTime)
+				return
+			}
+
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Template preview", counter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			responseData, _ = json.Marshal(preview)
+
+			finishResponse("Template preview", counter, w, http.StatusOK, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(set)
+
+	return
+}
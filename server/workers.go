@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var workersCounter uint64
+
+// WorkersRequest is the request data of Workers endpoint
+type WorkersRequest struct {
+	Count uint32 `json:"count"`
+	AppID string `json:"appId"`
+}
+
+// WorkersResponse is the response data of Workers endpoint
+type WorkersResponse struct {
+	Workers           int32  `json:"workers"`
+	ConfiguredWorkers uint32 `json:"configuredWorkers"`
+	Error             string `json:"error,omitempty"`
+}
+
+// NewWorkersHTTPHandlerFunc returns a net/http compatible request handler function that returns number of workers of a client (GET, appId query parameter)
+// or grows or shrinks its pool of workers (POST)
+func NewWorkersHTTPHandlerFunc(c *apns.Client, apps map[string]*apns.Client) (f http.HandlerFunc) {
+	f = func(c *apns.Client) http.HandlerFunc {
+		var handlerFunc http.HandlerFunc
+
+		handlerFunc = func(w http.ResponseWriter, req *http.Request) {
+			startTime := time.Now()
+
+			counter := atomic.AddUint64(&workersCounter, 1)
+
+			var responseData []byte
+
+			logger.Infof("Received workers request #%d", counter)
+
+			responseHeaders := w.Header()
+			responseHeaders.Set("Content-Type", "application/json; charset=utf8")
+
+			workersRequest := new(WorkersRequest)
+
+			switch req.Method {
+			case "GET":
+				workersRequest.AppID = req.URL.Query().Get("appId")
+
+			case "POST":
+				err := json.NewDecoder(req.Body).Decode(workersRequest)
+				if err == nil && workersRequest.Count == 0 {
+					err = errors.New("Number of workers must be positive")
+				}
+
+				if err != nil {
+					responseData = errorResponseData(err)
+					defer finishResponse("Workers", counter, w, http.StatusBadRequest, responseData, startTime)
+					return
+				}
+
+			default:
+				defer finishResponse("Workers", counter, w, http.StatusMethodNotAllowed, responseData, startTime)
+				return
+			}
+
+			client, err := selectClient(c, apps, workersRequest.AppID)
+			if err != nil {
+				responseData = errorResponseData(err)
+				defer finishResponse("Workers", counter, w, http.StatusConflict, responseData, startTime)
+				return
+			}
+
+			status := http.StatusOK
+			workersResponse := new(WorkersResponse)
+
+			if req.Method == "POST" {
+				err = client.SetWorkerCount(workersRequest.Count)
+				if err != nil {
+					status = http.StatusServiceUnavailable
+					workersResponse.Error = err.Error()
+				}
+			}
+
+			workersResponse.Workers = client.ActiveWorkers()
+			workersResponse.ConfiguredWorkers = client.WorkerCount()
+			responseData, _ = json.Marshal(workersResponse)
+
+			finishResponse("Workers", counter, w, status, responseData, startTime)
+		}
+
+		return handlerFunc
+	}(c)
+
+	return
+}
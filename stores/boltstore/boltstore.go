@@ -0,0 +1,162 @@
+// Package boltstore implements apns stores persisted in a bolt database file, suitable for single node deployments.
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	bolt "go.etcd.io/bbolt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	feedbackBucket     = []byte("feedback")
+	resultsBucket      = []byte("results")
+	resultsIndexBucket = []byte("results-index")
+)
+
+// Store persists apns data in a bolt database
+type Store struct {
+	// lock guards db which is replaced by compaction
+	lock sync.RWMutex
+	db   *bolt.DB
+
+	expired        uint64
+	compactions    uint64
+	lastCompaction time.Time
+
+	done chan bool
+}
+
+// Open opens or creates bolt database file at path
+func Open(path string) (store *Store, err error) {
+	var db *bolt.DB
+	db, err = bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{feedbackBucket, resultsBucket, resultsIndexBucket} {
+			if _, bucketErr := tx.CreateBucketIfNotExists(name); bucketErr != nil {
+				return bucketErr
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return
+	}
+
+	store = new(Store)
+	store.db = db
+	store.done = make(chan bool)
+
+	return
+}
+
+// Close stops housekeeping and closes the database
+func (s *Store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+
+	return s.db.Close()
+}
+
+// update runs fn in a read-write transaction of the current database
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.db.Update(fn)
+}
+
+// view runs fn in a read-only transaction of the current database
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.db.View(fn)
+}
+
+// Add implements apns.FeedbackStore interface
+func (s *Store) Add(entries []*apns.FeedbackDeviceEntry) error {
+	return s.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(feedbackBucket)
+
+		for _, entry := range entries {
+			sequence, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			value, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+
+			err = bucket.Put(sequenceKey(sequence), value)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Query implements apns.FeedbackStore interface. Cursor is the sequence number of the first entry to examine.
+func (s *Store) Query(since time.Time, cursor string, limit int) (entries []*apns.FeedbackDeviceEntry, next string, err error) {
+	var start uint64 = 1
+	if cursor != "" {
+		start, err = strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", apns.ErrInvalidCursor
+		}
+	}
+
+	entries = make([]*apns.FeedbackDeviceEntry, 0)
+	next = strconv.FormatUint(start, 10)
+
+	err = s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(feedbackBucket).Cursor()
+
+		for key, value := c.Seek(sequenceKey(start)); key != nil; key, value = c.Next() {
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+
+			next = strconv.FormatUint(binary.BigEndian.Uint64(key)+1, 10)
+
+			entry := apns.NewFeedbackDeviceEntry()
+			if err := json.Unmarshal(value, entry); err != nil {
+				return err
+			}
+
+			if !entry.Timestamp.Before(since) {
+				entries = append(entries, entry)
+			}
+		}
+
+		return nil
+	})
+
+	return
+}
+
+func sequenceKey(sequence uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+
+	return key
+}
@@ -0,0 +1,49 @@
+package boltstore
+
+import (
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFeedbackQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := Open(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	base := time.Unix(1445000000, 0)
+	var entries []*apns.FeedbackDeviceEntry
+	for i := 0; i < 3; i++ {
+		entry := apns.NewFeedbackDeviceEntry()
+		entry.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		entries = append(entries, entry)
+	}
+	assert.Nil(store.Add(entries), "Adding entries shouldn't produce error")
+
+	page, next, err := store.Query(time.Time{}, "", 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(page, 2, "First page should be full")
+
+	page, next, err = store.Query(time.Time{}, next, 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(page, 1, "Last page should hold the rest")
+	assert.True(base.Add(time.Hour*2).Equal(page[0].Timestamp), "Pages should follow each other")
+
+	page, _, err = store.Query(time.Time{}, next, 2)
+	assert.Nil(err, "Query shouldn't produce error")
+	assert.Len(page, 0, "Nothing was added after the last page")
+}
@@ -0,0 +1,197 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	bolt "go.etcd.io/bbolt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultExpireInterval is how often Housekeep removes expired entries
+const DefaultExpireInterval = time.Hour
+
+// compactionTxMaxSize is the maximum size of a single transaction while copying data to compacted database
+const compactionTxMaxSize = 64 * 1024 * 1024
+
+// Housekeep removes results and feedback entries older than ttl every DefaultExpireInterval and compacts the database file
+// every compactionInterval until the store is closed. Zero ttl keeps entries forever, zero compactionInterval disables compaction.
+func (s *Store) Housekeep(ttl time.Duration, compactionInterval time.Duration) {
+	expireTicker := time.NewTicker(DefaultExpireInterval)
+	defer expireTicker.Stop()
+
+	var compaction <-chan time.Time
+	if compactionInterval > 0 {
+		compactionTicker := time.NewTicker(compactionInterval)
+		defer compactionTicker.Stop()
+		compaction = compactionTicker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-expireTicker.C:
+			if ttl <= 0 {
+				continue
+			}
+
+			if _, err := s.Expire(time.Now().Add(-ttl)); err != nil {
+				logger.Errorf("Couldn't remove expired entries from %s: %s", s.path(), err)
+			}
+
+		case <-compaction:
+			if err := s.Compact(); err != nil {
+				logger.Errorf("Couldn't compact %s: %s", s.path(), err)
+			}
+		}
+	}
+}
+
+// Expire removes results updated and feedback entries reported before cutoff and returns the number of removed entries.
+// Feedback entries are stored in order of arrival so removal stops at the first entry that isn't expired.
+func (s *Store) Expire(cutoff time.Time) (removed int, err error) {
+	err = s.update(func(tx *bolt.Tx) error {
+		removed = 0
+
+		results := tx.Bucket(resultsBucket)
+		index := tx.Bucket(resultsIndexBucket)
+
+		var expiredKeys [][]byte
+		c := index.Cursor()
+		for key, _ := c.First(); key != nil && int64(binary.BigEndian.Uint64(key[:8])) < cutoff.UnixNano(); key, _ = c.Next() {
+			expiredKeys = append(expiredKeys, key)
+		}
+
+		for _, key := range expiredKeys {
+			if err := results.Delete(key[8:]); err != nil {
+				return err
+			}
+
+			if err := index.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed += len(expiredKeys)
+
+		feedback := tx.Bucket(feedbackBucket)
+
+		expiredKeys = nil
+		c = feedback.Cursor()
+		for key, value := c.First(); key != nil; key, value = c.Next() {
+			entry := apns.NewFeedbackDeviceEntry()
+			if err := json.Unmarshal(value, entry); err != nil {
+				return err
+			}
+
+			if !entry.Timestamp.Before(cutoff) {
+				break
+			}
+
+			expiredKeys = append(expiredKeys, key)
+		}
+
+		for _, key := range expiredKeys {
+			if err := feedback.Delete(key); err != nil {
+				return err
+			}
+		}
+		removed += len(expiredKeys)
+
+		return nil
+	})
+
+	if err == nil && removed > 0 {
+		atomic.AddUint64(&s.expired, uint64(removed))
+		logger.Infof("Removed %d expired entries from %s", removed, s.path())
+	}
+
+	return
+}
+
+// Compact copies the database into a new file, which releases space freed by removed entries, and replaces the database with it.
+// Requests to the store wait until compaction finishes.
+func (s *Store) Compact() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	path := s.db.Path()
+	compactedPath := path + ".compact"
+
+	compacted, err := bolt.Open(compactedPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+
+	err = bolt.Compact(compacted, s.db, compactionTxMaxSize)
+	compacted.Close()
+	if err != nil {
+		os.Remove(compactedPath)
+		return err
+	}
+
+	sizeBefore := fileSize(path)
+
+	if err = s.db.Close(); err != nil {
+		os.Remove(compactedPath)
+		return err
+	}
+
+	if err = os.Rename(compactedPath, path); err != nil {
+		os.Remove(compactedPath)
+	}
+
+	// the original database is reopened when replacing it failed
+	db, openErr := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if openErr != nil {
+		return openErr
+	}
+	s.db = db
+
+	if err != nil {
+		return err
+	}
+
+	s.compactions++
+	s.lastCompaction = time.Now()
+	logger.Infof("Compacted %s from %d to %d bytes", path, sizeBefore, fileSize(path))
+
+	return nil
+}
+
+// StoreStats implements apns.StoreStatsReporter interface
+func (s *Store) StoreStats() (stats apns.StoreStats) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	stats.SizeBytes = fileSize(s.db.Path())
+	stats.Expired = atomic.LoadUint64(&s.expired)
+	stats.Compactions = s.compactions
+	stats.LastCompaction = s.lastCompaction
+
+	s.db.View(func(tx *bolt.Tx) error {
+		stats.Entries = tx.Bucket(feedbackBucket).Stats().KeyN + tx.Bucket(resultsBucket).Stats().KeyN
+		return nil
+	})
+
+	return
+}
+
+func (s *Store) path() string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.db.Path()
+}
+
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
@@ -0,0 +1,61 @@
+package boltstore
+
+import (
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpireAndCompact(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "boltstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := Open(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	now := time.Now()
+	old := now.Add(-time.Hour * 48)
+
+	assert.Nil(store.Put(&apns.Result{Identifier: "a", Status: apns.ResultSent, Updated: old}))
+	assert.Nil(store.Put(&apns.Result{Identifier: "b", Status: apns.ResultQueued, Updated: old}))
+	assert.Nil(store.Put(&apns.Result{Identifier: "b", Status: apns.ResultSent, Updated: now}), "Updated result should be re-indexed")
+
+	expiredEntry := apns.NewFeedbackDeviceEntry()
+	expiredEntry.Timestamp = old
+	entry := apns.NewFeedbackDeviceEntry()
+	entry.Timestamp = now
+	assert.Nil(store.Add([]*apns.FeedbackDeviceEntry{expiredEntry, entry}))
+
+	removed, err := store.Expire(now.Add(-time.Hour * 24))
+	assert.Nil(err, "Expire shouldn't produce error")
+	assert.Equal(2, removed, "Expired result and feedback entry should be removed")
+
+	assert.Nil(store.Compact(), "Compaction shouldn't produce error")
+
+	results, err := store.Get([]string{"a", "b"})
+	assert.Nil(err)
+	assert.Len(results, 1, "Only recent result should be kept")
+	assert.Equal(apns.ResultSent, results["b"].Status)
+
+	entries, _, err := store.Query(time.Time{}, "", 0)
+	assert.Nil(err)
+	assert.Len(entries, 1, "Only recent feedback entry should be kept")
+
+	stats := store.StoreStats()
+	assert.Equal(2, stats.Entries)
+	assert.Equal(uint64(2), stats.Expired)
+	assert.Equal(uint64(1), stats.Compactions)
+	assert.True(stats.SizeBytes > 0)
+}
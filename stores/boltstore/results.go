@@ -0,0 +1,72 @@
+package boltstore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Put implements apns.ResultStore interface. Results are indexed by time of update so expired results can be removed without a full scan.
+func (s *Store) Put(result *apns.Result) error {
+	value, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		results := tx.Bucket(resultsBucket)
+		index := tx.Bucket(resultsIndexBucket)
+		key := []byte(result.Identifier)
+
+		if previous := results.Get(key); previous != nil {
+			previousResult := new(apns.Result)
+			if err := json.Unmarshal(previous, previousResult); err == nil {
+				if err := index.Delete(indexKey(previousResult)); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := results.Put(key, value); err != nil {
+			return err
+		}
+
+		return index.Put(indexKey(result), nil)
+	})
+}
+
+// Get implements apns.ResultStore interface
+func (s *Store) Get(identifiers []string) (results map[string]*apns.Result, err error) {
+	results = make(map[string]*apns.Result)
+
+	err = s.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(resultsBucket)
+
+		for _, identifier := range identifiers {
+			value := bucket.Get([]byte(identifier))
+			if value == nil {
+				continue
+			}
+
+			result := new(apns.Result)
+			if err := json.Unmarshal(value, result); err != nil {
+				return err
+			}
+
+			results[identifier] = result
+		}
+
+		return nil
+	})
+
+	return
+}
+
+// indexKey orders results by time of update followed by identifier
+func indexKey(result *apns.Result) []byte {
+	key := make([]byte, 8, 8+len(result.Identifier))
+	binary.BigEndian.PutUint64(key, uint64(result.Updated.UnixNano()))
+
+	return append(key, result.Identifier...)
+}
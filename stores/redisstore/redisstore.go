@@ -0,0 +1,126 @@
+// Package redisstore implements apns stores persisted in Redis, suitable for deployments sharing state between several nodes.
+package redisstore
+
+import (
+	"encoding/json"
+	"github.com/andrejbaran/apns-ms/apns"
+	"github.com/gomodule/redigo/redis"
+	"strconv"
+	"time"
+)
+
+// DefaultKeyPrefix is the default prefix of all Redis keys used by the store
+const DefaultKeyPrefix = "apns-ms:"
+
+const queryBatchSize = 100
+
+// Store persists apns data in Redis
+type Store struct {
+	pool      *redis.Pool
+	keyPrefix string
+}
+
+// NewPool returns a connection pool for Redis server at address
+func NewPool(address string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     4,
+		IdleTimeout: time.Minute * 5,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", address)
+		},
+	}
+}
+
+// New returns store using connections from pool and keys prefixed with keyPrefix
+func New(pool *redis.Pool, keyPrefix string) *Store {
+	store := new(Store)
+	store.pool = pool
+	store.keyPrefix = keyPrefix
+
+	return store
+}
+
+// Close closes the connection pool
+func (s *Store) Close() error {
+	return s.pool.Close()
+}
+
+func (s *Store) feedbackKey() string {
+	return s.keyPrefix + "feedback"
+}
+
+// Add implements apns.FeedbackStore interface
+func (s *Store) Add(entries []*apns.FeedbackDeviceEntry) (err error) {
+	if len(entries) == 0 {
+		return
+	}
+
+	args := redis.Args{}.Add(s.feedbackKey())
+	for _, entry := range entries {
+		var value []byte
+		value, err = json.Marshal(entry)
+		if err != nil {
+			return
+		}
+
+		args = args.Add(value)
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("RPUSH", args...)
+
+	return
+}
+
+// Query implements apns.FeedbackStore interface. Cursor is the list index of the first entry to examine.
+func (s *Store) Query(since time.Time, cursor string, limit int) (entries []*apns.FeedbackDeviceEntry, next string, err error) {
+	start := 0
+	if cursor != "" {
+		start, err = strconv.Atoi(cursor)
+		if err != nil || start < 0 {
+			return nil, "", apns.ErrInvalidCursor
+		}
+	}
+
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	entries = make([]*apns.FeedbackDeviceEntry, 0)
+	index := start
+
+	for limit <= 0 || len(entries) < limit {
+		var values [][]byte
+		values, err = redis.ByteSlices(conn.Do("LRANGE", s.feedbackKey(), index, index+queryBatchSize-1))
+		if err != nil {
+			return
+		}
+
+		for _, value := range values {
+			if limit > 0 && len(entries) == limit {
+				break
+			}
+
+			index++
+
+			entry := apns.NewFeedbackDeviceEntry()
+			err = json.Unmarshal(value, entry)
+			if err != nil {
+				return
+			}
+
+			if !entry.Timestamp.Before(since) {
+				entries = append(entries, entry)
+			}
+		}
+
+		if len(values) < queryBatchSize {
+			break
+		}
+	}
+
+	next = strconv.Itoa(index)
+
+	return
+}
@@ -0,0 +1,70 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/andrejbaran/apns-ms/apns"
+	"strconv"
+)
+
+// PayloadSizeWarningThreshold is the fraction of maximum payload size from which payload is reported as close to the limit
+const PayloadSizeWarningThreshold = 0.9
+
+// Preview is a rendered payload with its size and validation warnings
+type Preview struct {
+	Payload  json.RawMessage `json:"payload"`
+	Size     int             `json:"size"`
+	Warnings []string        `json:"warnings"`
+}
+
+// Preview renders template of given name and validates the resulting payload without sending it.
+// Error is returned when template can't be rendered or doesn't produce JSON object.
+func (s *Set) Preview(name string, variables map[string]interface{}) (*Preview, error) {
+	rendered, err := s.Render(name, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload map[string]interface{}
+	err = json.Unmarshal(rendered, &payload)
+	if err != nil {
+		return nil, errors.New("templates: Template " + name + " didn't render JSON object: " + err.Error())
+	}
+
+	// size of payload as it will be sent to APNS
+	compacted := new(bytes.Buffer)
+	json.Compact(compacted, rendered)
+
+	preview := &Preview{
+		Payload:  json.RawMessage(compacted.Bytes()),
+		Size:     compacted.Len(),
+		Warnings: make([]string, 0),
+	}
+
+	if preview.Size > apns.PayloadItemMaxLength {
+		preview.Warnings = append(preview.Warnings, "Payload size is "+strconv.Itoa(preview.Size)+" bytes but should be "+strconv.Itoa(apns.PayloadItemMaxLength)+" bytes at maximum")
+	} else if float64(preview.Size) >= float64(apns.PayloadItemMaxLength)*PayloadSizeWarningThreshold {
+		preview.Warnings = append(preview.Warnings, "Payload size is "+strconv.Itoa(preview.Size)+" bytes, close to maximum of "+strconv.Itoa(apns.PayloadItemMaxLength)+" bytes")
+	}
+
+	aps, ok := payload["aps"].(map[string]interface{})
+	if !ok {
+		preview.Warnings = append(preview.Warnings, "Payload is missing 'aps' dictionary")
+		return preview, nil
+	}
+
+	for key := range aps {
+		if !apns.KnownApsKeys[key] {
+			preview.Warnings = append(preview.Warnings, "Unknown 'aps' key '"+key+"'")
+		}
+	}
+
+	if alert, ok := aps["alert"]; !ok || alert == "" {
+		if aps["content-available"] == nil && aps["badge"] == nil && aps["sound"] == nil {
+			preview.Warnings = append(preview.Warnings, "Notification has no alert, badge, sound nor content-available, it won't have any effect")
+		}
+	}
+
+	return preview, nil
+}
@@ -0,0 +1,40 @@
+package templates
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSetPreview(t *testing.T) {
+	assert := assert.New(t)
+
+	s := NewSet()
+	assert.Nil(s.Add("order", `{"aps": {"alert": {{json .message}}, "sound": "default"}, "orderId": {{json .orderId}}}`), "Template should be valid")
+	assert.Nil(s.Add("silent", `{"aps": {"priority": 5}}`), "Template should be valid")
+
+	variables := map[string]interface{}{"message": `Your "order" shipped`, "orderId": 42}
+
+	rendered, err := s.Render("order", variables)
+	assert.Nil(err, "Render shouldn't produce error")
+	assert.Equal(`{"aps": {"alert": "Your \"order\" shipped", "sound": "default"}, "orderId": 42}`, string(rendered), "Variables should be JSON encoded")
+
+	preview, err := s.Preview("order", variables)
+	assert.Nil(err, "Preview shouldn't produce error")
+	assert.Equal(len(preview.Payload), preview.Size, "Size should be the size of compacted payload")
+	assert.Len(preview.Warnings, 0, "Valid payload shouldn't have warnings")
+
+	preview, err = s.Preview("order", map[string]interface{}{"message": strings.Repeat("x", 2100), "orderId": 42})
+	assert.Nil(err, "Preview of oversized payload shouldn't produce error")
+	assert.Contains(preview.Warnings[0], "should be 2048 bytes at maximum", "Oversized payload should be reported")
+
+	preview, err = s.Preview("silent", nil)
+	assert.Nil(err, "Preview shouldn't produce error")
+	assert.Len(preview.Warnings, 2, "Unknown aps key and missing alert should be reported")
+
+	_, err = s.Preview("order", map[string]interface{}{})
+	assert.NotNil(err, "Missing variable should produce error")
+
+	_, err = s.Preview("missing", nil)
+	assert.Equal(ErrUnknownTemplate, err, "Unknown template should be reported")
+}
@@ -0,0 +1,104 @@
+// Package templates renders notification payloads from text/template files.
+//
+// Every *.json file in templates directory is a template named after the file (without extension) which renders payload JSON.
+// Use json function to safely insert variables:
+//
+//	{"aps": {"alert": {"title": {{json .title}}, "body": {{json .body}}}, "sound": "default"}, "orderId": {{json .orderId}}}
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Extension is the file extension of template files
+const Extension = ".json"
+
+// ErrUnknownTemplate is returned when there is no template of given name
+var ErrUnknownTemplate = errors.New("templates: Unknown template")
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Set is a set of payload templates
+type Set struct {
+	templates map[string]*template.Template
+}
+
+// NewSet returns an empty set of templates
+func NewSet() *Set {
+	s := new(Set)
+	s.templates = make(map[string]*template.Template)
+
+	return s
+}
+
+// Load parses all template files of the directory
+func Load(dir string) (*Set, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewSet()
+	for _, file := range files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.Add(strings.TrimSuffix(filepath.Base(file), Extension), string(text))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Add parses template text and adds it to the set under given name, replacing template of the same name
+func (s *Set) Add(name string, text string) error {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return errors.New("templates: Couldn't parse template " + name + ": " + err.Error())
+	}
+
+	s.templates[name] = t
+
+	return nil
+}
+
+// Names returns names of all templates in the set
+func (s *Set) Names() []string {
+	names := make([]string, 0, len(s.templates))
+	for name := range s.templates {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// Render executes template of given name with variables and returns rendered payload
+func (s *Set) Render(name string, variables map[string]interface{}) ([]byte, error) {
+	t, ok := s.templates[name]
+	if !ok {
+		return nil, ErrUnknownTemplate
+	}
+
+	buffer := new(bytes.Buffer)
+	err := t.Execute(buffer, variables)
+	if err != nil {
+		return nil, errors.New("templates: Couldn't render template " + name + ": " + err.Error())
+	}
+
+	return buffer.Bytes(), nil
+}